@@ -0,0 +1,68 @@
+package maze
+
+// Neighbour bits of a blob autotile mask, clockwise from north.
+var blobBits = []struct {
+	d   direction
+	bit int
+}{
+	{Dir.Up, 1}, {D(1, -1), 2}, {Dir.Right, 4}, {D(1, 1), 8},
+	{Dir.Down, 16}, {D(-1, 1), 32}, {Dir.Left, 64}, {D(-1, -1), 128},
+}
+
+// blobIndex maps each of the 47 distinct blob masks to its tile index.
+var blobIndex = func() map[int]int {
+	index := make(map[int]int)
+	for mask := 0; mask < 256; mask++ {
+		if blobMask(mask) == mask {
+			index[mask] = len(index)
+		}
+	}
+	return index
+}()
+
+// blobMask clears every corner bit whose two adjacent edges are not both set,
+// since such a corner does not change how the tile looks.
+func blobMask(mask int) int {
+	for _, c := range []struct{ corner, a, b int }{{2, 1, 4}, {8, 4, 16}, {32, 16, 64}, {128, 64, 1}} {
+		if mask&c.a == 0 || mask&c.b == 0 {
+			mask &^= c.corner
+		}
+	}
+	return mask
+}
+
+// AutotileMap assigns each wall cell its tile in a standard 47-tile blob
+// tileset, indexed [y][x]; passable cells get -1.
+//
+// A wall's mask has a bit set for every wall neighbour: N=1, NE=2, E=4, SE=8,
+// S=16, SW=32, W=64, NW=128. A corner bit only counts when both edges next to
+// it are set, which leaves 47 distinct masks; the tile index is the mask's
+// position among them in increasing order, so 0 is an isolated pillar and 46
+// a wall surrounded by walls. Cells outside the grid count as walls, and secret
+// passages tile as the walls they pretend to be.
+func AutotileMap(g *Grid) [][]int {
+	wall := func(p Point) bool {
+		return !passable(g.At(p))
+	}
+
+	tiles := make([][]int, g.Size.Y)
+	for y := range tiles {
+		tiles[y] = make([]int, g.Size.X)
+		for x := range tiles[y] {
+			here := Pt(x, y)
+			if !wall(here) {
+				tiles[y][x] = -1
+				continue
+			}
+			mask := 0
+			for _, n := range blobBits {
+				if wall(here.AddDir(n.d)) {
+					mask |= n.bit
+				}
+			}
+			tiles[y][x] = blobIndex[blobMask(mask)]
+		}
+	}
+
+	return tiles
+}
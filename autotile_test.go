@@ -0,0 +1,30 @@
+package maze
+
+import "testing"
+
+func TestAutotileMap(t *testing.T) {
+	g := parseGrid(
+		"#####",
+		"#   #",
+		"# # #",
+		"#   #",
+		"#####",
+	)
+	tiles := AutotileMap(g)
+
+	for _, c := range []struct {
+		p    Point
+		want int
+	}{
+		{Pt(0, 0), 44}, // everything but SE: mask 247
+		{Pt(2, 0), 36}, // open to the south, so both south corners drop: mask 199
+		{Pt(0, 2), 42}, // open to the east: mask 241
+		{Pt(4, 2), 12}, // open to the west: mask 31
+		{Pt(2, 2), 0},  // isolated pillar
+		{Pt(1, 1), -1},
+	} {
+		if got := tiles[c.p.Y][c.p.X]; got != c.want {
+			t.Errorf("AutotileMap at %v = %d, want %d", c.p, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package maze
+
+import "fmt"
+
+// Check verifies the grid's internal invariants: both cell slices hold
+// Size.X*Size.Y entries, every region id lies in 0..regCount, every Carved or
+// Door cell has a region and every Rock cell has NoRegion. It returns an error
+// naming the first offending cell in row-major order, or nil.
+func (g *Grid) Check() error {
+	n := g.Size.X * g.Size.Y
+	if len(g.g) != n {
+		return fmt.Errorf("maze: %d materials for %dx%d grid", len(g.g), g.Size.X, g.Size.Y)
+	}
+	if len(g.regions) != n {
+		return fmt.Errorf("maze: %d regions for %dx%d grid", len(g.regions), g.Size.X, g.Size.Y)
+	}
+
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			p := Pt(x, y)
+			m, r := g.At(p), g.RegionAt(p)
+			switch {
+			case r < NoRegion || r > g.regCount:
+				return fmt.Errorf("maze: cell %s has region %d, want 0..%d", p, r, g.regCount)
+			case (m == Carved || m == Door) && r == NoRegion:
+				return fmt.Errorf("maze: carved cell %s has no region", p)
+			case m == Rock && r != NoRegion:
+				return fmt.Errorf("maze: rock cell %s has region %d", p, r)
+			}
+		}
+	}
+	return nil
+}
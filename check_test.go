@@ -0,0 +1,57 @@
+package maze
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCheckAfterEachStage(t *testing.T) {
+	ctx := context.Background()
+	cfg := testConfig(Pt(41, 31), 3)
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	g := newGrid(cfg.Size)
+
+	rooms, _, err := createRooms(ctx, g.Bounds(), cfg.Rooms, cfg.Rooms.Tries, g.allowed, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rooms {
+		g.carveRoom(r, "")
+	}
+	if err := g.Check(); err != nil {
+		t.Fatalf("after rooms: %s", err)
+	}
+
+	if err := growMaze(ctx, g, cfg, rng, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Check(); err != nil {
+		t.Fatalf("after growing corridors: %s", err)
+	}
+
+	if err := connectRegions(ctx, g, cfg, rng, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Check(); err != nil {
+		t.Fatalf("after connecting regions: %s", err)
+	}
+}
+
+func TestCheckNamesOffendingCell(t *testing.T) {
+	g := parseGrid(
+		"#####",
+		"#   #",
+		"#####",
+	)
+	if err := g.Check(); err != nil {
+		t.Fatalf("Check() on a valid grid: %s", err)
+	}
+
+	g.SetMaterial(Pt(2, 0), Carved)
+	err := g.Check()
+	if err == nil || !strings.Contains(err.Error(), Pt(2, 0).String()) {
+		t.Errorf("Check() with an unregioned carved cell at %v = %v", Pt(2, 0), err)
+	}
+}
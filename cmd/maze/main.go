@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wolverian/maze"
+)
+
+const (
+	IMG_WIDTH  = 61
+	IMG_HEIGHT = 61
+)
+
+// Longest side, in pixels, of the thumbnail written with -thumb.
+const THUMB_SIZE = 128
+
+var placements = map[string]maze.Placement{
+	"uniform": maze.Uniform,
+	"poisson": maze.PoissonDisk,
+}
+
+var algorithms = map[string]maze.Algorithm{
+	"growing-tree": maze.GrowingTree,
+	"backtracker":  maze.Backtracker,
+	"unicursal":    maze.Unicursal,
+}
+
+var shapes = map[string]maze.Shape{
+	"rectangle": maze.Rectangle,
+	"circle":    maze.Circle,
+}
+
+var exits = map[string]maze.ExitPlacement{
+	"farthest": maze.FarthestExit,
+	"leaf":     maze.LeafExit,
+}
+
+// presetFields copies each setting a preset may change from the preset's
+// config, keyed by the flag that overrides it.
+var presetFields = map[string]func(cfg *maze.Config, preset maze.Config){
+	"min":            func(cfg *maze.Config, p maze.Config) { cfg.Rooms.Min = p.Rooms.Min },
+	"max":            func(cfg *maze.Config, p maze.Config) { cfg.Rooms.Max = p.Rooms.Max },
+	"rooms":          func(cfg *maze.Config, p maze.Config) { cfg.Rooms.Tries = p.Rooms.Tries },
+	"roominess":      func(cfg *maze.Config, p maze.Config) { cfg.Rooms.Roominess = p.Rooms.Roominess },
+	"winding":        func(cfg *maze.Config, p maze.Config) { cfg.WindingPercent = p.WindingPercent },
+	"max-run":        func(cfg *maze.Config, p maze.Config) { cfg.MaxCorridorRun = p.MaxCorridorRun },
+	"hub-bias":       func(cfg *maze.Config, p maze.Config) { cfg.HubBias = p.HubBias },
+	"loops":          func(cfg *maze.Config, p maze.Config) { cfg.ExtraConnectors = p.ExtraConnectors },
+	"secrets":        func(cfg *maze.Config, p maze.Config) { cfg.SecretChance = p.SecretChance },
+	"remove-pillars": func(cfg *maze.Config, p maze.Config) { cfg.RemovePillars = p.RemovePillars },
+}
+
+var widthFlag = flag.Int("width", IMG_WIDTH, "maze `width` in cells")
+
+var heightFlag = flag.Int("height", IMG_HEIGHT, "maze `height` in cells")
+
+var seedFlag = flag.Int64("seed", 0, "random `seed`; a time-based seed is used and printed if unset")
+
+var roomsFlag = flag.Int("rooms", maze.ROOM_TRIES, "`number` of attempts at placing a room")
+
+var minFlag = flag.Int("min", 5, "smallest room side, in cells")
+
+var maxFlag = flag.Int("max", 15, "largest room side, in cells")
+
+var placementFlag = flag.String("placement", "uniform", "room `placement`: uniform or poisson")
+
+var algorithmFlag = flag.String("algorithm", "growing-tree", "corridor `algorithm`: growing-tree, backtracker or unicursal")
+
+var shapeFlag = flag.String("shape", "rectangle", "maze `shape`: rectangle or circle")
+
+var exitFlag = flag.String("exit", "farthest", "exit `placement`: farthest, or leaf for a room with a single way in")
+
+var windingFlag = flag.Int("winding", 100, "`percent` chance that a corridor turns when it could carry straight on")
+
+var maxRunFlag = flag.Int("max-run", 0, "end corridors after `n` unbranched steps; 0 means no limit")
+
+var hubBiasFlag = flag.Float64("hub-bias", 0, "favor doors into larger rooms by this `power` of their size; 0 means no bias")
+
+var loopsFlag = flag.Int("loops", 0, "open `n` extra connectors once the maze is connected, each adding a loop")
+
+var secretsFlag = flag.Float64("secrets", 0, "`chance` that a wall between two facing dead ends becomes a secret passage")
+
+var pillarsFlag = flag.Bool("remove-pillars", false, "carve out lone rock cells left standing in corridors")
+
+var segmentsFlag = flag.Bool("segments", false, "color every room, junction and corridor run between them as a region of its own")
+
+var roominessFlag = flag.Float64("roominess", 1, "above 1 favors larger rooms, below 1 smaller ones")
+
+var themeFlag = flag.String("theme", "", "start from a preset look, "+strings.Join(maze.PresetNames(), ", ")+"; other flags override it")
+
+var revealFlag = flag.Bool("reveal", false, "draw secret passages instead of disguising them as rock")
+
+var boxFlag = flag.Bool("box", false, "draw the walls of text output with box-drawing characters")
+
+var roundFlag = flag.Bool("round", false, "round off the corners of walls in images drawn with -scale 4 or more")
+
+var rulerFlag = flag.Int("ruler", 0, "mark cell coordinates every `n` cells along the image's edges")
+
+var outPath = flag.String("out", "maze.png", "write the maze to this `path`, in the format its extension names")
+
+var thumbPath = flag.String("thumb", "", "also write a thumbnail of the maze image to this `path`")
+
+var manifestPath = flag.String("manifest", "", "also write a JSON manifest of the maze, its seed, config and files to this `path`")
+
+var framesFlag = flag.String("frames", "", "also write generation steps as numbered PNG frames into this `directory`")
+
+var solveGIFPath = flag.String("solve-gif", "", "also write an animated GIF of the maze being solved to this `path`")
+
+var frameEveryFlag = flag.Int("frame-every", 1, "write a frame every `n` generation or solver steps")
+
+var scaleFlag = flag.Int("scale", 1, "draw every cell as an `n`×n block of pixels, or units in SVG")
+
+func main() {
+	flag.Parse()
+
+	if *scaleFlag < 1 {
+		log.Fatalf("Scale must be at least 1, got %d\n", *scaleFlag)
+	}
+
+	placement, ok := placements[*placementFlag]
+	if !ok {
+		log.Fatalf("Unknown room placement '%s'\n", *placementFlag)
+	}
+	algorithm, ok := algorithms[*algorithmFlag]
+	if !ok {
+		log.Fatalf("Unknown algorithm '%s'\n", *algorithmFlag)
+	}
+	shape, ok := shapes[*shapeFlag]
+	if !ok {
+		log.Fatalf("Unknown shape '%s'\n", *shapeFlag)
+	}
+	exitPlacement, ok := exits[*exitFlag]
+	if !ok {
+		log.Fatalf("Unknown exit placement '%s'\n", *exitFlag)
+	}
+
+	cfg := maze.Config{
+		Size: maze.Pt(*widthFlag, *heightFlag),
+		Rooms: maze.RoomParams{
+			Min:       maze.Pt(*minFlag, *minFlag),
+			Max:       maze.Pt(*maxFlag, *maxFlag),
+			Tries:     *roomsFlag,
+			Roominess: *roominessFlag,
+		},
+		Seed:            *seedFlag,
+		Placement:       placement,
+		Algorithm:       algorithm,
+		Shape:           shape,
+		Exit:            exitPlacement,
+		WindingPercent:  *windingFlag,
+		MaxCorridorRun:  *maxRunFlag,
+		HubBias:         *hubBiasFlag,
+		ExtraConnectors: *loopsFlag,
+		SecretChance:    *secretsFlag,
+		RemovePillars:   *pillarsFlag,
+		SegmentRegions:  *segmentsFlag,
+	}
+	seeded, opts := false, maze.RenderOptions{RevealSecrets: *revealFlag, BoxDrawing: *boxFlag, RoundCorners: *roundFlag, RulerEvery: *rulerFlag}
+	if *themeFlag != "" {
+		preset, theme, err := maze.Preset(*themeFlag, cfg.Size)
+		if err != nil {
+			log.Fatalf("%s\n", err)
+		}
+		set := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		for name, copyField := range presetFields {
+			if !set[name] {
+				copyField(&cfg, preset)
+			}
+		}
+		opts.Theme = &theme
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("%s\n", err)
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		seeded = seeded || f.Name == "seed"
+		if f.Name == "scale" {
+			opts.Scale = *scaleFlag
+		}
+	})
+	if !seeded {
+		cfg.Seed = time.Now().UnixNano()
+		log.Printf("Using seed %d\n", cfg.Seed)
+	}
+
+	var onStep func(*maze.Grid)
+	var frames *maze.FrameRecorder
+	if *framesFlag != "" {
+		if err := os.MkdirAll(*framesFlag, 0755); err != nil {
+			log.Fatalf("Can not create frame directory '%s': %s\n", *framesFlag, err)
+		}
+		frames = maze.RecordFrames(*framesFlag, *frameEveryFlag)
+		onStep = frames.Step
+	}
+
+	grid, entrance, exit, err := maze.Generate(context.Background(), cfg, onStep)
+	if err != nil {
+		log.Fatalf("%s\n", err)
+	}
+	if frames != nil && frames.Err() != nil {
+		log.Fatalf("Can not write frames: %s\n", frames.Err())
+	}
+
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(*outPath), "."))
+	if format == "png" {
+		writeImageAnnotated(grid, nil, opts, *outPath, *thumbPath)
+	} else {
+		writeFormat(grid, format, opts, *outPath)
+	}
+	if *manifestPath != "" {
+		writeManifest(grid, cfg, *manifestPath)
+	}
+	if *solveGIFPath != "" {
+		writeSolveGIF(grid, entrance, exit, opts, *solveGIFPath)
+	}
+}
+
+func writeSolveGIF(g *maze.Grid, entrance, exit maze.Point, opts maze.RenderOptions, file string) {
+	var buf bytes.Buffer
+	if err := g.AnimateSolve(&buf, entrance, exit, *frameEveryFlag, opts); err != nil {
+		log.Fatalf("Can not animate solving the maze for '%s': %s\n", file, err)
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		log.Fatalf("Can not write animation to '%s': %s\n", file, err)
+	}
+}
+
+func writeManifest(g *maze.Grid, cfg maze.Config, file string) {
+	files := []string{*outPath}
+	if *thumbPath != "" {
+		files = append(files, *thumbPath)
+	}
+	var buf bytes.Buffer
+	if err := maze.WriteManifest(&buf, g, cfg, files...); err != nil {
+		log.Fatalf("Can not describe maze for '%s': %s\n", file, err)
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0644); err != nil {
+		log.Fatalf("Can not write manifest to '%s': %s\n", file, err)
+	}
+}
+
+func writeFormat(g *maze.Grid, format string, opts maze.RenderOptions, file string) {
+	var buf bytes.Buffer
+	err := g.Render(&buf, format, opts)
+	if err != nil {
+		log.Fatalf("Can not render maze for '%s': %s\n", file, err)
+	}
+
+	err = os.WriteFile(file, buf.Bytes(), 0644)
+	if err != nil {
+		log.Fatalf("Can not write maze to '%s': %s\n", file, err)
+	}
+}
+
+func writeImageAnnotated(g *maze.Grid, path []maze.Point, opts maze.RenderOptions, file, thumb string) {
+	img := g.RenderAnnotated(path, opts)
+
+	writePNG(img, file)
+	if thumb != "" {
+		writePNG(thumbnail(img, THUMB_SIZE), thumb)
+	}
+}
+
+func writePNG(img image.Image, file string) {
+	w, err := os.Create(file)
+	defer w.Close()
+	if err != nil {
+		log.Fatalf("Can not create file '%s': %s\n", file, err)
+	}
+
+	err = png.Encode(w, img)
+	if err != nil {
+		log.Fatalf("Can not write image to '%s': %s\n", file, err)
+	}
+}
+
+// thumbnail scales img down with nearest-neighbour sampling so that its
+// longer side is at most size pixels, keeping the aspect ratio. Images that
+// already fit are returned unchanged.
+func thumbnail(img *image.Paletted, size int) *image.Paletted {
+	b := img.Bounds()
+	long := max(b.Dx(), b.Dy())
+	if long <= size {
+		return img
+	}
+
+	w, h := max(1, b.Dx()*size/long), max(1, b.Dy()*size/long)
+	thumb := image.NewPaletted(image.Rect(0, 0, w, h), img.Palette)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			thumb.SetColorIndex(x, y, img.ColorIndexAt(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h))
+		}
+	}
+
+	return thumb
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"image"
+	"image/color/palette"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	for _, c := range []struct {
+		w, h, size int
+		want       image.Point
+	}{
+		{640, 480, 128, image.Pt(128, 96)},
+		{300, 900, 128, image.Pt(42, 128)},
+		{2000, 3, 128, image.Pt(128, 1)},
+		{100, 60, 128, image.Pt(100, 60)},
+	} {
+		img := image.NewPaletted(image.Rect(0, 0, c.w, c.h), palette.Plan9)
+		got := thumbnail(img, c.size).Bounds().Size()
+		if got != c.want {
+			t.Errorf("thumbnail(%dx%d, %d) is %v, want %v", c.w, c.h, c.size, got, c.want)
+		}
+		if got.X > c.size || got.Y > c.size {
+			t.Errorf("thumbnail(%dx%d, %d) is %v, larger than %d", c.w, c.h, c.size, got, c.size)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package maze
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+// unconnected returns a grid with rooms and corridors carved but its regions
+// not yet connected.
+func unconnected(tb testing.TB, size Point, seed int64) *Grid {
+	tb.Helper()
+	cfg := testConfig(size, seed)
+	cfg.Rooms.Tries = size.X * size.Y / 200
+	rng := rand.New(rand.NewSource(seed))
+	g := newGrid(size)
+	rooms, _, err := createRooms(context.Background(), g.Bounds(), cfg.Rooms, cfg.Rooms.Tries, g.allowed, rng)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for _, r := range rooms {
+		g.carveRoom(r, "")
+	}
+	if err := growMaze(context.Background(), g, cfg, rng, nil); err != nil {
+		tb.Fatal(err)
+	}
+	return g
+}
+
+func cloneGrid(g *Grid) *Grid {
+	c := *g
+	c.g = append([]Material{}, g.g...)
+	c.regions = append([]Region{}, g.regions...)
+	return &c
+}
+
+// connectByRescan is connectRegions as it was before regionSets: every merge
+// relabels the merged region's cells, scanning the whole grid.
+func connectByRescan(g *Grid, rng *rand.Rand) {
+	remaining := findConnectors(g)
+	if len(remaining) == 0 {
+		return
+	}
+	sides := func(c connector) (Region, Region) {
+		return g.RegionAt(c.loc.AddDir(c.a.dir)), g.RegionAt(c.loc.AddDir(c.b.dir))
+	}
+	main, _ := sides(remaining[rng.Intn(len(remaining))])
+
+	for {
+		bridges := make([]connector, 0)
+		for _, c := range remaining {
+			if ra, rb := sides(c); (ra == main) != (rb == main) {
+				bridges = append(bridges, c)
+			}
+		}
+		if len(bridges) == 0 {
+			return
+		}
+
+		c := bridges[rng.Intn(len(bridges))]
+		merged, _ := sides(c)
+		if merged == main {
+			_, merged = sides(c)
+		}
+		g.SetMaterial(c.loc, Door)
+		g.SetRegion(c.loc, main)
+		for i, r := range g.regions {
+			if r == merged {
+				g.regions[i] = main
+			}
+		}
+
+		kept := remaining[:0]
+		for _, o := range remaining {
+			if ra, rb := sides(o); o.loc != c.loc && (ra != main || rb != main) {
+				kept = append(kept, o)
+			}
+		}
+		remaining = kept
+	}
+}
+
+// connectBySets is connectRegions before it tracked bridges incrementally:
+// merges go into regionSets, but the bridges are found again among all the
+// remaining connectors after every merge.
+func connectBySets(g *Grid, rng *rand.Rand) {
+	remaining := findConnectors(g)
+	if len(remaining) == 0 {
+		return
+	}
+	sets := newRegionSets(g.regCount)
+	sides := func(c connector) (Region, Region) {
+		return sets.find(g.RegionAt(c.loc.AddDir(c.a.dir))), sets.find(g.RegionAt(c.loc.AddDir(c.b.dir)))
+	}
+	main, _ := sides(remaining[rng.Intn(len(remaining))])
+
+	for {
+		bridges := make([]connector, 0)
+		for _, c := range remaining {
+			if ra, rb := sides(c); (ra == main) != (rb == main) {
+				bridges = append(bridges, c)
+			}
+		}
+		if len(bridges) == 0 {
+			break
+		}
+
+		c := bridges[rng.Intn(len(bridges))]
+		merged, _ := sides(c)
+		if merged == main {
+			_, merged = sides(c)
+		}
+		g.SetMaterial(c.loc, Door)
+		g.SetRegion(c.loc, main)
+		sets.union(main, merged)
+
+		kept := remaining[:0]
+		for _, o := range remaining {
+			if ra, rb := sides(o); o.loc != c.loc && (ra != main || rb != main) {
+				kept = append(kept, o)
+			}
+		}
+		remaining = kept
+	}
+	for i, r := range g.regions {
+		g.regions[i] = sets.find(r)
+	}
+}
+
+func TestConnectRegions(t *testing.T) {
+	base := unconnected(t, Pt(61, 61), 20)
+	for name, connect := range map[string]func(*Grid, *rand.Rand){
+		"rescan":     connectByRescan,
+		"union-find": connectBySets,
+		"connectRegions": func(g *Grid, rng *rand.Rand) {
+			if err := connectRegions(context.Background(), g, Config{}, rng, nil); err != nil {
+				t.Fatal(err)
+			}
+		},
+	} {
+		g := cloneGrid(base)
+		connect(g, rand.New(rand.NewSource(1)))
+		if !g.IsFullyConnected(Moves{}) {
+			t.Errorf("%s: maze is not fully connected", name)
+		}
+		if regions := g.Regions(); len(regions) != 1 {
+			t.Errorf("%s: %d regions left after connecting, want 1", name, len(regions))
+		}
+		if conns := findConnectors(g); len(conns) != 0 {
+			t.Errorf("%s: %d connectors left between regions", name, len(conns))
+		}
+	}
+}
+
+func TestHubBias(t *testing.T) {
+	// degrees returns the average number of doors of the largest room of
+	// each maze, and of the other rooms.
+	degrees := func(bias float64) (largest, others float64) {
+		n := 0
+		for seed := int64(1); seed <= 20; seed++ {
+			cfg := testConfig(Pt(61, 41), seed)
+			cfg.HubBias = bias
+			g, _, _ := mustGenerate(t, cfg)
+			if err := g.Check(); err != nil {
+				t.Fatalf("bias %g, seed %d: %s", bias, seed, err)
+			}
+			big := 0
+			for i, r := range g.rooms {
+				if r.Dx()*r.Dy() > g.rooms[big].Dx()*g.rooms[big].Dy() {
+					big = i
+				}
+			}
+			for i, r := range g.rooms {
+				if d := float64(len(roomOpenings(g, r))); i == big {
+					largest += d / 20
+				} else {
+					others += d
+					n++
+				}
+			}
+		}
+		return largest, others / float64(n)
+	}
+
+	plain, _ := degrees(0)
+	largest, others := degrees(2)
+	if largest <= others || largest <= plain {
+		t.Errorf("largest rooms have %.2f doors on average, other rooms %.2f and largest rooms without bias %.2f", largest, others, plain)
+	}
+}
+
+// BenchmarkConnectRegions compares, on a 201×201 maze, relabelling the grid on
+// every merge, tracking merges in regionSets but finding the bridges again
+// after each, and connectRegions itself.
+func BenchmarkConnectRegions(b *testing.B) {
+	base := unconnected(b, Pt(201, 201), 21)
+	for _, bench := range []struct {
+		name    string
+		connect func(*Grid, *rand.Rand)
+	}{
+		{"rescan", connectByRescan},
+		{"union-find", connectBySets},
+		{"union-find+bridges", func(g *Grid, rng *rand.Rand) {
+			connectRegions(context.Background(), g, Config{}, rng, nil)
+		}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				g := cloneGrid(base)
+				b.StartTimer()
+				bench.connect(g, rand.New(rand.NewSource(1)))
+			}
+		})
+	}
+}
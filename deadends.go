@@ -0,0 +1,110 @@
+package maze
+
+import (
+	"image"
+	"math/rand"
+)
+
+// DeadEnds says which dead ends RemoveDeadEnds may fill.
+type DeadEnds int
+
+// CorridorDeadEnds are dead-end corridors; rooms are never filled, so a room
+// with a single way in is kept as a vault, along with the corridor to it.
+// AnyDeadEnds also counts as a dead end every such room holding nothing but
+// floor, and fills it along with the corridor leading to it.
+const (
+	CorridorDeadEnds DeadEnds = iota
+	AnyDeadEnds
+)
+
+// RemoveDeadEnds sparsifies the maze by picking, at random with rng, the given
+// fraction of the dead ends which says to consider and filling each one's
+// corridor back in with rock up to the nearest junction. The other dead ends
+// are left as they are. A fraction of 1 leaves only corridors that lie on
+// loops or between rooms. Corridors ending in a secret passage are never
+// filled, nor are rooms beside one, so nothing that was connected gets cut
+// off.
+func (g *Grid) RemoveDeadEnds(fraction float64, which DeadEnds, rng *rand.Rand) {
+	ends := make([]Point, 0)
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if g.removableDeadEnd(Pt(x, y)) {
+				ends = append(ends, Pt(x, y))
+			}
+		}
+	}
+	// A room is filled from its top-left cell.
+	rooms := make(map[Point]image.Rectangle)
+	if which == AnyDeadEnds {
+		for _, r := range g.rooms {
+			if g.removableRoom(r) {
+				rooms[Pt(r.Min.X, r.Min.Y)] = r
+				ends = append(ends, Pt(r.Min.X, r.Min.Y))
+			}
+		}
+	}
+	rng.Shuffle(len(ends), func(i, j int) { ends[i], ends[j] = ends[j], ends[i] })
+	keep := int(float64(len(ends)) * (1 - fraction))
+
+	for _, p := range ends[:len(ends)-max(keep, 0)] {
+		if r, ok := rooms[p]; ok {
+			if !g.removableRoom(r) {
+				continue
+			}
+			p = roomOpenings(g, r)[0]
+			g.fillRoom(r)
+		}
+		for g.removableDeadEnd(p) {
+			next := g.passages(p)[0]
+			g.SetMaterial(p, Rock)
+			g.SetRegion(p, NoRegion)
+			p = next
+		}
+	}
+}
+
+func (g *Grid) removableDeadEnd(p Point) bool {
+	if m := g.At(p); m != Carved && m != Door || !isDeadEnd(g, p) || g.inRoom(p) {
+		return false
+	}
+	for _, d := range Dirs {
+		if g.At(p.AddDir(d)) == Secret {
+			return false
+		}
+	}
+	return true
+}
+
+// removableRoom reports whether r has a single way in, only Carved cells and
+// no secret passage beside it.
+func (g *Grid) removableRoom(r image.Rectangle) bool {
+	if len(roomOpenings(g, r)) != 1 {
+		return false
+	}
+	for y := r.Min.Y - 1; y <= r.Max.Y; y++ {
+		for x := r.Min.X - 1; x <= r.Max.X; x++ {
+			p := Pt(x, y)
+			if m := g.At(p); p.In(r) && m != Carved || m == Secret {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// fillRoom turns r back into rock and forgets it as a room.
+func (g *Grid) fillRoom(r image.Rectangle) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			g.SetMaterial(Pt(x, y), Rock)
+			g.SetRegion(Pt(x, y), NoRegion)
+		}
+	}
+	for i, o := range g.rooms {
+		if o == r {
+			g.rooms = append(g.rooms[:i:i], g.rooms[i+1:]...)
+			g.roomTypes = append(g.roomTypes[:i:i], g.roomTypes[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package maze
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func countRemovable(g *Grid) int {
+	n := 0
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if g.removableDeadEnd(Pt(x, y)) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestRemoveDeadEnds(t *testing.T) {
+	for _, fraction := range []float64{0, 0.1, 0.5, 1} {
+		g, entrance, exit := mustGenerate(t, testConfig(Pt(61, 61), 16))
+		before := countRemovable(g)
+		rooms := make(map[Point]Material)
+		for _, r := range g.Rooms() {
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				for x := r.Min.X; x < r.Max.X; x++ {
+					rooms[Pt(x, y)] = g.At(Pt(x, y))
+				}
+			}
+		}
+
+		g.RemoveDeadEnds(fraction, CorridorDeadEnds, rand.New(rand.NewSource(1)))
+
+		want := int(float64(before) * (1 - fraction))
+		if got := countRemovable(g); got != want {
+			t.Errorf("RemoveDeadEnds(%g) left %d of %d dead ends, want %d", fraction, got, before, want)
+		}
+		if !g.IsFullyConnected(Moves{}) || !g.EntranceConnected(entrance, exit) {
+			t.Errorf("RemoveDeadEnds(%g) disconnected the maze", fraction)
+		}
+		for p, m := range rooms {
+			if g.At(p) != m {
+				t.Fatalf("RemoveDeadEnds(%g) changed room cell %v", fraction, p)
+			}
+		}
+		if err := g.Check(); err != nil {
+			t.Errorf("RemoveDeadEnds(%g): %s", fraction, err)
+		}
+	}
+}
+
+func TestRemoveDeadEndsRooms(t *testing.T) {
+	// The left room's only way in is through the right room; the corridor
+	// below has a stub.
+	rows := []string{
+		"#########",
+		"#   #   #",
+		"#   +   #",
+		"#   #   #",
+		"#####+###",
+		"#<     >#",
+		"### #####",
+		"#########",
+	}
+	left, right := image.Rect(1, 1, 4, 4), image.Rect(5, 1, 8, 4)
+	for _, which := range []DeadEnds{CorridorDeadEnds, AnyDeadEnds} {
+		g := parseGrid(rows...)
+		g.rooms, g.roomTypes = []image.Rectangle{left, right}, []string{"", ""}
+		g.RemoveDeadEnds(1, which, rand.New(rand.NewSource(1)))
+
+		if g.At(Pt(3, 6)) != Rock {
+			t.Errorf("%d: corridor stub left", which)
+		}
+		vault := which == CorridorDeadEnds
+		if got := g.At(Pt(2, 2)) == Carved && g.At(Pt(4, 2)) == Door; got != vault {
+			t.Errorf("%d: dead-end room kept %t, want %t", which, got, vault)
+		}
+		if want := map[bool]int{true: 2, false: 1}[vault]; len(g.rooms) != want {
+			t.Errorf("%d: %d rooms left, want %d", which, len(g.rooms), want)
+		}
+		if g.At(Pt(6, 2)) != Carved || g.At(Pt(5, 4)) != Door {
+			t.Errorf("%d: room with two ways in was filled", which)
+		}
+		if err := g.Check(); err != nil {
+			t.Errorf("%d: %s", which, err)
+		}
+	}
+
+	g, entrance, exit := mustGenerate(t, testConfig(Pt(61, 61), 16))
+	g.RemoveDeadEnds(1, AnyDeadEnds, rand.New(rand.NewSource(1)))
+	if !g.IsFullyConnected(Moves{}) || !g.EntranceConnected(entrance, exit) {
+		t.Error("removing every dead end and dead-end room disconnected the maze")
+	}
+	if err := g.Check(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,260 @@
+package maze
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Largest grid, in cells, that DecodeRLE, ReadBinary and LoadGrid accept.
+const MAX_DECODE_CELLS = 1 << 24
+
+// checkDecodeSize reports whether a w×h grid is neither empty nor larger than
+// MAX_DECODE_CELLS, without overflowing on huge sizes.
+func checkDecodeSize(w, h int) bool {
+	return w > 0 && h > 0 && w <= MAX_DECODE_CELLS/h
+}
+
+// labelRegions gives each connected area of passable cells a region of its
+// own, numbered from 1, for formats that don't store regions, and returns how
+// many areas there are.
+func (g *Grid) labelRegions() Region {
+	labels, count := g.Components(Moves{})
+	for p, c := range labels {
+		g.SetRegion(p, Region(c+1))
+	}
+	return Region(count)
+}
+
+// EncodeRLE writes the grid's materials run-length encoded row by row. The
+// first line holds the width and height; every following line is one row of
+// space-separated count:material runs. Regions are not stored.
+func (g *Grid) EncodeRLE(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d %d\n", g.Size.X, g.Size.Y)
+
+	for y := 0; y < g.Size.Y; y++ {
+		x := 0
+		for x < g.Size.X {
+			m := g.At(Pt(x, y))
+			n := 1
+			for x+n < g.Size.X && g.At(Pt(x+n, y)) == m {
+				n++
+			}
+			if x > 0 {
+				bw.WriteByte(' ')
+			}
+			fmt.Fprintf(bw, "%d:%d", n, m)
+			x += n
+		}
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+// DecodeRLE reads a grid written by EncodeRLE. Each connected area of the
+// grid becomes a region of its own.
+func DecodeRLE(r io.Reader) (*Grid, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<24)
+
+	if !sc.Scan() {
+		return nil, fmt.Errorf("maze: rle: missing size header")
+	}
+	var size Point
+	if _, err := fmt.Sscanf(sc.Text(), "%d %d", &size.X, &size.Y); err != nil {
+		return nil, fmt.Errorf("maze: rle: bad size header %q: %s", sc.Text(), err)
+	}
+	if !checkDecodeSize(size.X, size.Y) {
+		return nil, fmt.Errorf("maze: rle: bad size %dx%d", size.X, size.Y)
+	}
+
+	// Collect the cells before making the grid, so that memory grows with the
+	// rows actually read rather than the size claimed.
+	cells := make([]Material, 0, size.X)
+	for y := 0; y < size.Y; y++ {
+		if !sc.Scan() {
+			return nil, fmt.Errorf("maze: rle: missing row %d", y)
+		}
+		x := 0
+		for _, run := range strings.Fields(sc.Text()) {
+			count, mat, ok := strings.Cut(run, ":")
+			if !ok {
+				return nil, fmt.Errorf("maze: rle: row %d: bad run %q", y, run)
+			}
+			n, err := strconv.Atoi(count)
+			if err != nil || n <= 0 || x+n > size.X {
+				return nil, fmt.Errorf("maze: rle: row %d: bad run length %q", y, run)
+			}
+			m, err := strconv.Atoi(mat)
+			if err != nil || m < Rock || m > StairsUp {
+				return nil, fmt.Errorf("maze: rle: row %d: bad material %q", y, run)
+			}
+			for ; n > 0; n-- {
+				cells = append(cells, Material(m))
+				x++
+			}
+		}
+		if x != size.X {
+			return nil, fmt.Errorf("maze: rle: row %d has %d cells, want %d", y, x, size.X)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	g := newGrid(size)
+	copy(g.g, cells)
+	g.regCount = g.labelRegions()
+	return g, nil
+}
+
+// The binary .maze format, all integers big-endian:
+//
+//	magic    [4]byte  "MAZE"
+//	version  uint8    binaryVersion
+//	width    uint32
+//	height   uint32
+//	cells    [(width*height+1)/2]byte  materials, 4 bits each, high nibble first
+//	regions  uint32   region count
+const binaryVersion = 1
+
+var binaryMagic = []byte("MAZE")
+
+var ErrNotMaze = errors.New("maze: not a .maze file")
+
+// WriteBinary writes the grid in the binary .maze format.
+func (g *Grid) WriteBinary(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.Write(binaryMagic)
+	bw.WriteByte(binaryVersion)
+	binary.Write(bw, binary.BigEndian, [2]uint32{uint32(g.Size.X), uint32(g.Size.Y)})
+
+	cells := make([]byte, (len(g.g)+1)/2)
+	for i, m := range g.g {
+		cells[i/2] |= byte(m&0xf) << (4 * (1 - i%2))
+	}
+	bw.Write(cells)
+	binary.Write(bw, binary.BigEndian, uint32(g.regCount))
+
+	return bw.Flush()
+}
+
+// ReadBinary reads a grid in the binary .maze format. Region ids are not
+// stored, only their count, so each connected area of the grid becomes a
+// region of its own.
+func ReadBinary(r io.Reader) (*Grid, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(binaryMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || !bytes.Equal(magic, binaryMagic) {
+		return nil, ErrNotMaze
+	}
+	version, err := br.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("maze: reading version: %s", err)
+	}
+	if version != binaryVersion {
+		return nil, fmt.Errorf("maze: unsupported .maze version %d", version)
+	}
+
+	var size [2]uint32
+	if err := binary.Read(br, binary.BigEndian, &size); err != nil {
+		return nil, fmt.Errorf("maze: reading size: %s", err)
+	}
+	if !checkDecodeSize(int(size[0]), int(size[1])) {
+		return nil, fmt.Errorf("maze: bad size %dx%d", size[0], size[1])
+	}
+
+	// Read the cells before making the grid, so that a short file can't
+	// claim a huge size and have it allocated.
+	n := int64(size[0]) * int64(size[1])
+	cells, err := io.ReadAll(io.LimitReader(br, (n+1)/2))
+	if err != nil {
+		return nil, fmt.Errorf("maze: reading cells: %s", err)
+	}
+	if int64(len(cells)) != (n+1)/2 {
+		return nil, fmt.Errorf("maze: reading cells: %s", io.ErrUnexpectedEOF)
+	}
+	g := newGrid(Pt(int(size[0]), int(size[1])))
+	for i := range g.g {
+		m := Material(cells[i/2]>>(4*(1-i%2))) & 0xf
+		if m > StairsUp {
+			return nil, fmt.Errorf("maze: bad material %d at cell %d", m, i)
+		}
+		g.g[i] = m
+	}
+
+	var regions uint32
+	if err := binary.Read(br, binary.BigEndian, &regions); err != nil {
+		return nil, fmt.Errorf("maze: reading region count: %s", err)
+	}
+	if int64(regions) > n {
+		return nil, fmt.Errorf("maze: %d regions for %d cells", regions, n)
+	}
+	g.regCount = max(Region(regions), g.labelRegions())
+
+	return g, nil
+}
+
+// gridJSON is the JSON form of a Grid, materials and regions in row-major
+// order.
+type gridJSON struct {
+	Size        Point
+	Materials   []Material
+	Regions     []Region
+	RegionCount Region
+	Rooms       []image.Rectangle
+	RoomTypes   []string `json:",omitempty"`
+}
+
+// MarshalJSON encodes the whole grid, regions and rooms and their types
+// included.
+func (g *Grid) MarshalJSON() ([]byte, error) {
+	return json.Marshal(gridJSON{g.Size, g.g, g.regions, g.regCount, g.rooms, g.roomTypes})
+}
+
+// LoadGrid reads a grid written as JSON by MarshalJSON.
+func LoadGrid(r io.Reader) (*Grid, error) {
+	var j gridJSON
+	if err := json.NewDecoder(r).Decode(&j); err != nil {
+		return nil, fmt.Errorf("maze: json: %s", err)
+	}
+	if !checkDecodeSize(j.Size.X, j.Size.Y) {
+		return nil, fmt.Errorf("maze: json: bad size %dx%d", j.Size.X, j.Size.Y)
+	}
+	n := j.Size.X * j.Size.Y
+	if len(j.Materials) != n || len(j.Regions) != n {
+		return nil, fmt.Errorf("maze: json: got %d materials and %d regions, want %d of each", len(j.Materials), len(j.Regions), n)
+	}
+	for i, m := range j.Materials {
+		if m < Rock || m > StairsUp {
+			return nil, fmt.Errorf("maze: json: bad material %d at cell %d", m, i)
+		}
+	}
+	if j.RegionCount < NoRegion || int(j.RegionCount) > n {
+		return nil, fmt.Errorf("maze: json: region count %d, want 0..%d", j.RegionCount, n)
+	}
+	for i, r := range j.Regions {
+		if r < NoRegion || r > j.RegionCount {
+			return nil, fmt.Errorf("maze: json: region %d at cell %d, want 0..%d", r, i, j.RegionCount)
+		}
+	}
+
+	if j.RoomTypes == nil {
+		j.RoomTypes = make([]string, len(j.Rooms))
+	}
+	if len(j.RoomTypes) != len(j.Rooms) {
+		return nil, fmt.Errorf("maze: json: got %d room types for %d rooms", len(j.RoomTypes), len(j.Rooms))
+	}
+
+	return &Grid{j.Materials, j.Size, j.Regions, j.RegionCount, j.Rooms, j.RoomTypes, nil}, nil
+}
@@ -0,0 +1,169 @@
+package maze
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sameCells reports the first cell whose material differs between a and b.
+func sameCells(t *testing.T, a, b *Grid) {
+	t.Helper()
+	if a.Size != b.Size {
+		t.Fatalf("size %v, want %v", b.Size, a.Size)
+	}
+	for y := 0; y < a.Size.Y; y++ {
+		for x := 0; x < a.Size.X; x++ {
+			if p := Pt(x, y); a.At(p) != b.At(p) {
+				t.Fatalf("At(%v) = %d, want %d", p, b.At(p), a.At(p))
+			}
+		}
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	g, _, _ := mustGenerate(t, testConfig(Pt(31, 21), 11))
+	var buf bytes.Buffer
+	if err := g.WriteBinary(&buf); err != nil {
+		t.Fatal(err)
+	}
+	h, err := ReadBinary(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameCells(t, g, h)
+	if h.regCount != g.regCount {
+		t.Errorf("region count %d, want %d", h.regCount, g.regCount)
+	}
+	if err := h.Check(); err != nil {
+		t.Error(err)
+	}
+	if got := len(h.Regions()); got != 1 {
+		t.Errorf("read maze has %d regions, want 1", got)
+	}
+}
+
+// binaryHeader returns the start of a .maze file for a w×h grid.
+func binaryHeader(version byte, w, h uint32) []byte {
+	var buf bytes.Buffer
+	buf.Write(binaryMagic)
+	buf.WriteByte(version)
+	binary.Write(&buf, binary.BigEndian, [2]uint32{w, h})
+	return buf.Bytes()
+}
+
+func TestReadBinaryMalformed(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"bad magic", []byte("MAZY\x01")},
+		{"bad version", binaryHeader(9, 3, 3)},
+		{"truncated size", binaryHeader(binaryVersion, 3, 3)[:7]},
+		{"zero size", binaryHeader(binaryVersion, 0, 3)},
+		{"huge size", binaryHeader(binaryVersion, 32768, 32768)},
+		{"too many cells", binaryHeader(binaryVersion, 1<<16, 1<<16)},
+		{"truncated cells", append(binaryHeader(binaryVersion, 3, 3), 0, 0)},
+		{"largest size, no cells", binaryHeader(binaryVersion, 4096, 4096)},
+		{"bad material", append(binaryHeader(binaryVersion, 1, 1), 0xf0, 0, 0, 0, 0)},
+		{"missing region count", append(binaryHeader(binaryVersion, 1, 1), 0)},
+		{"too many regions", append(binaryHeader(binaryVersion, 1, 1), 0, 0xff, 0xff, 0xff, 0xff)},
+	} {
+		if g, err := ReadBinary(bytes.NewReader(c.data)); err == nil {
+			t.Errorf("%s: ReadBinary = %v, want an error", c.name, g.Size)
+		}
+	}
+}
+
+func TestRLERoundTrip(t *testing.T) {
+	g, _, _ := mustGenerate(t, testConfig(Pt(31, 21), 12))
+	var buf bytes.Buffer
+	if err := g.EncodeRLE(&buf); err != nil {
+		t.Fatal(err)
+	}
+	h, err := DecodeRLE(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameCells(t, g, h)
+	if err := h.Check(); err != nil {
+		t.Error(err)
+	}
+	if got := len(h.Regions()); got != 1 {
+		t.Errorf("decoded maze has %d regions, want 1", got)
+	}
+}
+
+func TestDecodeRLEMalformed(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"3\n",
+		"0 3\n",
+		"-3 3\n",
+		"3037000500 3037000500\n",
+		"65536 65536\n",
+		"3 1\n3:0 1:0\n",
+		"3 1\n2:0\n",
+		"3 1\n3-0\n",
+		"3 1\n3:9\n",
+		"3 2\n3:0\n",
+	} {
+		if g, err := DecodeRLE(bytes.NewReader([]byte(data))); err == nil {
+			t.Errorf("DecodeRLE(%q) = %v, want an error", data, g.Size)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	g, _, _ := mustGenerate(t, testConfig(Pt(31, 21), 13))
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := LoadGrid(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sameCells(t, g, h)
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if p := Pt(x, y); g.RegionAt(p) != h.RegionAt(p) {
+				t.Fatalf("RegionAt(%v) = %d, want %d", p, h.RegionAt(p), g.RegionAt(p))
+			}
+		}
+	}
+	if !reflect.DeepEqual(g.Rooms(), h.Rooms()) {
+		t.Errorf("rooms %v, want %v", h.Rooms(), g.Rooms())
+	}
+
+	var a, b bytes.Buffer
+	png.Encode(&a, g.RenderAnnotated(nil, RenderOptions{}))
+	png.Encode(&b, h.RenderAnnotated(nil, RenderOptions{}))
+	if !bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Error("reloaded grid renders differently")
+	}
+}
+
+func TestLoadGridMalformed(t *testing.T) {
+	for _, data := range []string{
+		`{`,
+		`{"Size":{"X":0,"Y":1},"Materials":[],"Regions":[]}`,
+		`{"Size":{"X":4294967296,"Y":4294967296},"Materials":[],"Regions":[]}`,
+		`{"Size":{"X":2,"Y":1},"Materials":[1],"Regions":[1],"RegionCount":1}`,
+		`{"Size":{"X":1,"Y":1},"Materials":[9],"Regions":[0],"RegionCount":0}`,
+		`{"Size":{"X":1,"Y":1},"Materials":[1],"Regions":[7],"RegionCount":0}`,
+		`{"Size":{"X":1,"Y":1},"Materials":[1],"Regions":[-1],"RegionCount":1}`,
+		`{"Size":{"X":1,"Y":1},"Materials":[0],"Regions":[0],"RegionCount":-1}`,
+		`{"Size":{"X":1,"Y":1},"Materials":[0],"Regions":[0],"RegionCount":4611686018427387904}`,
+	} {
+		if g, err := LoadGrid(strings.NewReader(data)); err == nil {
+			t.Errorf("LoadGrid(%s) = %v, want an error", data, g.Size)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package maze
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Delays, in hundredths of a second, between the frames AnimateSolve writes,
+// and of its last frame before the animation loops.
+const (
+	SOLVE_FRAME_DELAY = 4
+	SOLVE_HOLD_DELAY  = 200
+)
+
+// FrameRecorder writes generation steps to a directory as numbered PNG
+// frames; see RecordFrames.
+type FrameRecorder struct {
+	dir   string
+	every int
+	steps int
+	// Frames is the number of frames written so far.
+	Frames int
+	err    error
+}
+
+// RecordFrames returns a recorder whose Step method, passed to Generate as
+// onStep, draws the grid's regions to dir/frame-0000.png, dir/frame-0001.png
+// and so on after every every steps, starting with the first. dir must
+// exist. The first error stops the recording and is kept for Err.
+func RecordFrames(dir string, every int) *FrameRecorder {
+	return &FrameRecorder{dir: dir, every: max(every, 1)}
+}
+
+// Step records g if a frame is due.
+func (r *FrameRecorder) Step(g *Grid) {
+	r.steps++
+	if r.err != nil || (r.steps-1)%r.every != 0 {
+		return
+	}
+	r.err = r.write(g)
+}
+
+func (r *FrameRecorder) write(g *Grid) error {
+	theme := DefaultTheme()
+	img := image.NewPaletted(g.Bounds(), theme.palette())
+	g.RenderRegions(img, RenderOptions{Theme: &theme})
+
+	path := filepath.Join(r.dir, fmt.Sprintf("frame-%04d.png", r.Frames))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("maze: writing %s: %s", path, err)
+	}
+	r.Frames++
+	return f.Close()
+}
+
+// Err returns the error that stopped the recording, if any.
+func (r *FrameRecorder) Err() error {
+	return r.err
+}
+
+// AnimateSolve writes an animated GIF of Solve searching from start to goal:
+// the grid in its material colors, opts.Scale pixels square per cell, with the
+// cells the breadth-first search has reached filling in with the theme's
+// Explored color, a frame after every every cells, and a last frame showing
+// the path found in the theme's Path color, held for SOLVE_HOLD_DELAY before
+// the animation loops. It fails if goal can't be reached from start.
+func (g *Grid) AnimateSolve(w io.Writer, start, goal Point, every int, opts RenderOptions) error {
+	scale, theme := opts.scale(1), opts.theme()
+	every = max(every, 1)
+	img := image.NewPaletted(scaled(g.Bounds(), scale), theme.palette())
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			fillCell(img, Pt(x, y), scale, theme.material(g.At(Pt(x, y)), opts.RevealSecrets))
+		}
+	}
+
+	anim := &gif.GIF{}
+	frame := func(delay int) {
+		f := image.NewPaletted(img.Rect, img.Palette)
+		copy(f.Pix, img.Pix)
+		anim.Image = append(anim.Image, f)
+		anim.Delay = append(anim.Delay, delay)
+	}
+	if !passable(g.At(start)) || !passable(g.At(goal)) {
+		return fmt.Errorf("maze: no path from %v to %v", start, goal)
+	}
+	visited := 0
+	path, ok := shortestPath(start, goal, g.passages, func(p Point) {
+		fillCell(img, p, scale, theme.Explored)
+		if visited++; visited%every == 0 {
+			frame(SOLVE_FRAME_DELAY)
+		}
+	})
+	if !ok {
+		return fmt.Errorf("maze: no path from %v to %v", start, goal)
+	}
+	renderPath(img, path, scale, theme)
+	renderEnds(img, g, scale, theme)
+	frame(SOLVE_HOLD_DELAY)
+
+	return gif.EncodeAll(w, anim)
+}
@@ -0,0 +1,105 @@
+package maze
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordFrames(t *testing.T) {
+	dir := t.TempDir()
+	steps := 0
+	rec := RecordFrames(dir, 10)
+	cfg := testConfig(Pt(21, 21), 14)
+	if _, _, _, err := Generate(context.Background(), cfg, func(g *Grid) { steps++; rec.Step(g) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (steps + 9) / 10; rec.Frames != want {
+		t.Errorf("%d frames for %d steps, want %d", rec.Frames, steps, want)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.png"))
+	if len(files) != rec.Frames {
+		t.Errorf("%d files for %d frames", len(files), rec.Frames)
+	}
+	f, err := os.Open(filepath.Join(dir, fmt.Sprintf("frame-%04d.png", rec.Frames-1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds().Size(); got != cfg.Size.Point {
+		t.Errorf("frame is %v, want %v", got, cfg.Size)
+	}
+
+	rec = RecordFrames(filepath.Join(dir, "missing"), 1)
+	rec.Step(newGrid(cfg.Size))
+	if rec.Err() == nil {
+		t.Error("recording into a missing directory succeeded")
+	}
+}
+
+func TestAnimateSolve(t *testing.T) {
+	// The goal is two steps from the start, so the search stops long before
+	// it reaches the far end of the corridor.
+	g := parseGrid(
+		"#########",
+		"#     # #",
+		"# ### # #",
+		"#   #   #",
+		"#########",
+	)
+	start, goal := Pt(1, 1), Pt(1, 3)
+	var buf bytes.Buffer
+	if err := g.AnimateSolve(&buf, start, goal, 2, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, _ := g.Solve(start, goal)
+	visited := 0
+	shortestPath(start, goal, g.passages, func(Point) { visited++ })
+	if want := visited/2 + 1; len(anim.Image) != want {
+		t.Fatalf("%d frames for %d cells searched, want %d", len(anim.Image), visited, want)
+	}
+	if last := anim.Delay[len(anim.Delay)-1]; last != SOLVE_HOLD_DELAY {
+		t.Errorf("last frame held %d, want %d", last, SOLVE_HOLD_DELAY)
+	}
+
+	theme := DefaultTheme()
+	colorAt := func(i int, p Point) color.Color {
+		return color.RGBAModel.Convert(anim.Image[i].At(p.X, p.Y))
+	}
+	if got, want := colorAt(0, start), color.RGBAModel.Convert(theme.Explored); got != want {
+		t.Errorf("start drawn %v in the first frame, want %v", got, want)
+	}
+	if got, want := colorAt(0, Pt(7, 1)), color.RGBAModel.Convert(theme.Carved); got != want {
+		t.Errorf("cell past the goal drawn %v in the first frame, want %v", got, want)
+	}
+	for _, p := range path {
+		if got, want := colorAt(len(anim.Image)-1, p), color.RGBAModel.Convert(theme.Path); got != want {
+			t.Errorf("path cell %v drawn %v in the last frame, want %v", p, got, want)
+		}
+	}
+
+	if err := g.AnimateSolve(&buf, start, Pt(7, 3), 1, RenderOptions{}); err != nil {
+		t.Errorf("AnimateSolve to a reachable cell: %s", err)
+	}
+	if err := g.AnimateSolve(&buf, start, Pt(0, 0), 1, RenderOptions{}); err == nil {
+		t.Error("AnimateSolve into rock succeeded")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/wolverian/maze
+
+go 1.21
@@ -0,0 +1,468 @@
+package maze
+
+var Diagonals = []direction{D(1, -1), D(1, 1), D(-1, 1), D(-1, -1)}
+
+// Connectivity says which neighbours of a cell a step can reach.
+type Connectivity int
+
+const (
+	// Orthogonal steps go up, down, left or right, as corridors are carved.
+	Orthogonal Connectivity = iota
+	// Diagonal steps may also go to the four diagonal neighbours.
+	Diagonal
+)
+
+// Moves says which steps Solve, Components, DistanceFrom and the neighbour
+// helpers may take from a cell. The zero value steps orthogonally between
+// passable cells, as the maze is carved.
+type Moves struct {
+	Connectivity Connectivity
+	// CutCorners lets a diagonal step squeeze between two rock cells.
+	// Otherwise both orthogonal cells it passes must be passable as well.
+	CutCorners bool
+	// AllowSecrets counts secret passages as passable, as for a player who
+	// has discovered them.
+	AllowSecrets bool
+}
+
+// dirs returns the directions a step can take under m.
+func (m Moves) dirs() []direction {
+	if m.Connectivity == Diagonal {
+		return append(append([]direction{}, Dirs...), Diagonals...)
+	}
+	return Dirs
+}
+
+// cornerOpen reports whether a step from p in direction d stays clear of
+// corners under m. Orthogonal steps always do.
+func (g *Grid) cornerOpen(p Point, d direction, m Moves) bool {
+	if m.CutCorners || d.X == 0 || d.Y == 0 {
+		return true
+	}
+	return Passable(g.At(p.AddDir(D(d.X, 0))), m.AllowSecrets) && Passable(g.At(p.AddDir(D(0, d.Y))), m.AllowSecrets)
+}
+
+// WallGraph returns the dual of the passage graph: every rock cell is a node,
+// linked to each rock cell it shares at least one corner with.
+func (g *Grid) WallGraph() map[Point][]Point {
+	bounds := g.Bounds()
+	graph := make(map[Point][]Point)
+	dirs := append(append([]direction{}, Dirs...), Diagonals...)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			here := Pt(x, y)
+			if passable(g.At(here)) {
+				continue
+			}
+			adj := make([]Point, 0)
+			for _, d := range dirs {
+				there := here.AddDir(d)
+				if there.In(bounds) && !passable(g.At(there)) {
+					adj = append(adj, there)
+				}
+			}
+			graph[here] = adj
+		}
+	}
+
+	return graph
+}
+
+// passages returns the in-bounds carved cells orthogonally adjacent to p.
+func (g *Grid) passages(p Point) []Point {
+	ps := make([]Point, 0, len(Dirs))
+	for _, d := range Dirs {
+		n := p.AddDir(d)
+		if passable(g.At(n)) {
+			ps = append(ps, n)
+		}
+	}
+	return ps
+}
+
+// moves returns the passable cells one step from p under m.
+func (g *Grid) moves(p Point, m Moves) []Point {
+	dirs := m.dirs()
+	ps := make([]Point, 0, len(dirs))
+	for _, d := range dirs {
+		n := p.AddDir(d)
+		if Passable(g.At(n), m.AllowSecrets) && g.cornerOpen(p, d, m) {
+			ps = append(ps, n)
+		}
+	}
+	return ps
+}
+
+// CarvedNeighbors returns the in-bounds Carved or Door cells one step from p
+// under m. Unlike the passage graph it leaves out the entrance, the exit and
+// secret passages.
+func (g *Grid) CarvedNeighbors(p Point, m Moves) []Point {
+	dirs := m.dirs()
+	ns := make([]Point, 0, len(dirs))
+	for _, d := range dirs {
+		n := p.AddDir(d)
+		if !g.InBounds(n) || !g.cornerOpen(p, d, m) {
+			continue
+		}
+		if mat := g.At(n); mat == Carved || mat == Door {
+			ns = append(ns, n)
+		}
+	}
+	return ns
+}
+
+// Degree returns the number of CarvedNeighbors of p under m.
+func (g *Grid) Degree(p Point, m Moves) int {
+	return len(g.CarvedNeighbors(p, m))
+}
+
+// Chokepoints returns the articulation points of the passage graph: carved
+// cells whose removal would split their component in two. It uses Tarjan's
+// low-link algorithm and returns the cells in row-major order.
+func Chokepoints(g *Grid) []Point {
+	bounds := g.Bounds()
+	order := make(map[Point]int)
+	low := make(map[Point]int)
+	cut := make(map[Point]bool)
+
+	var visit func(p, parent Point, root bool)
+	visit = func(p, parent Point, root bool) {
+		order[p] = len(order) + 1
+		low[p] = order[p]
+		children := 0
+
+		for _, n := range g.passages(p) {
+			if order[n] == 0 {
+				children++
+				visit(n, p, false)
+				low[p] = min(low[p], low[n])
+				if !root && low[n] >= order[p] {
+					cut[p] = true
+				}
+			} else if root || n != parent {
+				low[p] = min(low[p], order[n])
+			}
+		}
+
+		if root && children > 1 {
+			cut[p] = true
+		}
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			here := Pt(x, y)
+			if passable(g.At(here)) && order[here] == 0 {
+				visit(here, here, true)
+			}
+		}
+	}
+
+	points := make([]Point, 0, len(cut))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if cut[Pt(x, y)] {
+				points = append(points, Pt(x, y))
+			}
+		}
+	}
+
+	return points
+}
+
+// Components labels every carved cell with the id of its connected component
+// when stepping under m, found by flood fill and independent of the region
+// bookkeeping. Ids start at 0; the component count is returned alongside.
+func (g *Grid) Components(m Moves) (map[Point]int, int) {
+	bounds := g.Bounds()
+	labels := make(map[Point]int)
+	count := 0
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			start := Pt(x, y)
+			if !Passable(g.At(start), m.AllowSecrets) {
+				continue
+			}
+			if _, seen := labels[start]; seen {
+				continue
+			}
+
+			labels[start] = count
+			stack := []Point{start}
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				for _, n := range g.moves(p, m) {
+					if _, seen := labels[n]; !seen {
+						labels[n] = count
+						stack = append(stack, n)
+					}
+				}
+			}
+			count++
+		}
+	}
+
+	return labels, count
+}
+
+// IsFullyConnected reports whether every passable cell can be reached from
+// every other when stepping under m. It is trivially true with fewer than two
+// passable cells.
+func (g *Grid) IsFullyConnected(m Moves) bool {
+	_, count := g.Components(m)
+	return count <= 1
+}
+
+// DistanceFrom returns the breadth-first step count from `from` to every carved
+// cell reachable from it when stepping under m. Unreachable cells, and every
+// cell if from is not passable, have no entry.
+func (g *Grid) DistanceFrom(from Point, m Moves) map[Point]int {
+	if !Passable(g.At(from), m.AllowSecrets) {
+		return make(map[Point]int)
+	}
+	return distances(from, func(p Point) []Point { return g.moves(p, m) })
+}
+
+// distances returns the breadth-first step count from `from` to every cell
+// reachable from it, stepping from each cell to the cells next returns for it.
+func distances[T comparable](from T, next func(T) []T) map[T]int {
+	dist := map[T]int{from: 0}
+	queue := []T{from}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, n := range next(p) {
+			if _, seen := dist[n]; !seen {
+				dist[n] = dist[p] + 1
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	return dist
+}
+
+// FlowField points every carved cell that can reach goal under m in the
+// direction of its neighbour one step closer to it. The goal itself and
+// unreachable cells have no entry.
+func FlowField(g *Grid, goal Point, m Moves) map[Point]direction {
+	dist := g.DistanceFrom(goal, m)
+	field := make(map[Point]direction, len(dist))
+
+	for p, d := range dist {
+		if d == 0 {
+			continue
+		}
+		for _, dir := range m.dirs() {
+			if n, ok := dist[p.AddDir(dir)]; ok && n == d-1 && g.cornerOpen(p, dir, m) {
+				field[p] = dir
+				break
+			}
+		}
+	}
+
+	return field
+}
+
+// CountPaths counts the simple paths (no cell visited twice) from start to end
+// through carved cells, stepping under m. The number of simple paths grows exponentially with
+// the number of loops, so the search stops as soon as limit paths have been
+// found and returns limit; a result below limit is the exact count.
+func CountPaths(g *Grid, start, end Point, limit int, m Moves) int {
+	if !Passable(g.At(start), m.AllowSecrets) || !Passable(g.At(end), m.AllowSecrets) {
+		return 0
+	}
+
+	visited := make(map[Point]bool)
+	count := 0
+
+	var walk func(p Point)
+	walk = func(p Point) {
+		if p == end {
+			count++
+			return
+		}
+		visited[p] = true
+		for _, n := range g.moves(p, m) {
+			if count >= limit {
+				break
+			}
+			if !visited[n] {
+				walk(n)
+			}
+		}
+		visited[p] = false
+	}
+
+	walk(start)
+	return min(count, limit)
+}
+
+// EntranceConnected reports whether exit can be reached from entrance through
+// passable cells.
+func (g *Grid) EntranceConnected(entrance, exit Point) bool {
+	_, ok := g.Solve(entrance, exit)
+	return ok
+}
+
+// farthest returns the cell farthest from `from` along orthogonal passages and
+// its distance, breaking ties by row-major order so the result is
+// deterministic.
+func (g *Grid) farthest(from Point) (Point, int) {
+	best, far := from, 0
+	for p, d := range g.DistanceFrom(from, Moves{}) {
+		if d > far || d == far && (p.Y < best.Y || p.Y == best.Y && p.X < best.X) {
+			best, far = p, d
+		}
+	}
+	return best, far
+}
+
+// firstPassable returns the first passable cell in row-major order.
+func (g *Grid) firstPassable() (Point, bool) {
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if passable(g.At(Pt(x, y))) {
+				return Pt(x, y), true
+			}
+		}
+	}
+	return Point{}, false
+}
+
+// VertexConnectivity returns the minimum number of carved cells that must be
+// removed to separate the two ends of the maze's longest shortest path, found
+// by double breadth-first search from the first carved cell. It is 1 for a
+// perfect maze and grows as loops add independent routes. The cut is found as
+// a maximum flow over the passage graph with every cell split into an in and
+// an out node joined by a unit-capacity edge. It returns 0 when there are no
+// two non-adjacent cells to separate.
+func VertexConnectivity(g *Grid) int {
+	first, ok := g.firstPassable()
+	if !ok {
+		return 0
+	}
+	s, _ := g.farthest(first)
+	t, dist := g.farthest(s)
+	if dist < 2 {
+		return 0
+	}
+
+	ids := make(map[Point]int)
+	for p := range g.DistanceFrom(s, Moves{}) {
+		ids[p] = len(ids)
+	}
+
+	type edge struct{ to, cap int }
+	edges := make([]edge, 0)
+	adj := make([][]int, 2*len(ids))
+	link := func(u, v int) {
+		adj[u] = append(adj[u], len(edges))
+		edges = append(edges, edge{v, 1})
+		adj[v] = append(adj[v], len(edges))
+		edges = append(edges, edge{u, 0})
+	}
+
+	for p, i := range ids {
+		link(2*i, 2*i+1)
+		for _, n := range g.passages(p) {
+			link(2*i+1, 2*ids[n])
+		}
+	}
+
+	source, sink := 2*ids[s]+1, 2*ids[t]
+	flow := 0
+	for {
+		via := make([]int, len(adj))
+		for i := range via {
+			via[i] = -1
+		}
+		via[source] = len(edges)
+		queue := []int{source}
+		for len(queue) > 0 && via[sink] == -1 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, e := range adj[u] {
+				if v := edges[e].to; edges[e].cap > 0 && via[v] == -1 {
+					via[v] = e
+					queue = append(queue, v)
+				}
+			}
+		}
+		if via[sink] == -1 {
+			return flow
+		}
+		for v := sink; v != source; v = edges[via[v]^1].to {
+			edges[via[v]].cap--
+			edges[via[v]^1].cap++
+		}
+		flow++
+	}
+}
+
+// Girth returns the length of the shortest cycle in the passage graph, or -1
+// if there is none, as in a perfect maze. Dead-end branches are peeled off
+// first since they cannot lie on a cycle, then a breadth-first search from
+// each remaining cell finds the shortest cycle through it.
+func Girth(g *Grid) int {
+	core := make(map[Point]int)
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if p := Pt(x, y); passable(g.At(p)) {
+				core[p] = len(g.passages(p))
+			}
+		}
+	}
+	leaves := make([]Point, 0)
+	for p, deg := range core {
+		if deg <= 1 {
+			leaves = append(leaves, p)
+		}
+	}
+	for len(leaves) > 0 {
+		p := leaves[len(leaves)-1]
+		leaves = leaves[:len(leaves)-1]
+		delete(core, p)
+		for _, n := range g.passages(p) {
+			if _, ok := core[n]; ok {
+				core[n]--
+				if core[n] == 1 {
+					leaves = append(leaves, n)
+				}
+			}
+		}
+	}
+
+	girth := -1
+	for s := range core {
+		dist := map[Point]int{s: 0}
+		parent := map[Point]Point{s: s}
+		queue := []Point{s}
+		for len(queue) > 0 {
+			p := queue[0]
+			queue = queue[1:]
+			if girth > 0 && 2*dist[p]+1 >= girth {
+				break
+			}
+			for _, n := range g.passages(p) {
+				if _, ok := core[n]; !ok {
+					continue
+				}
+				if d, seen := dist[n]; !seen {
+					dist[n] = dist[p] + 1
+					parent[n] = p
+					queue = append(queue, n)
+				} else if parent[p] != n {
+					if c := dist[p] + d + 1; girth < 0 || c < girth {
+						girth = c
+					}
+				}
+			}
+		}
+	}
+
+	return girth
+}
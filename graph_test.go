@@ -0,0 +1,289 @@
+package maze
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCarvedNeighbors(t *testing.T) {
+	g := parseGrid(
+		"  +#",
+		"    ",
+		"#  <",
+	)
+
+	for _, c := range []struct {
+		p    Point
+		want []Point
+	}{
+		{Pt(0, 0), []Point{Pt(1, 0), Pt(0, 1)}},
+		{Pt(1, 0), []Point{Pt(0, 0), Pt(2, 0), Pt(1, 1)}},
+		{Pt(3, 1), []Point{Pt(2, 1)}},
+		{Pt(1, 1), []Point{Pt(1, 0), Pt(0, 1), Pt(2, 1), Pt(1, 2)}},
+		{Pt(2, 1), []Point{Pt(2, 0), Pt(1, 1), Pt(3, 1), Pt(2, 2)}},
+	} {
+		got := sortPoints(g.CarvedNeighbors(c.p, Moves{}))
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("CarvedNeighbors(%v) = %v, want %v", c.p, got, c.want)
+		}
+		if d := g.Degree(c.p, Moves{}); d != len(c.want) {
+			t.Errorf("Degree(%v) = %d, want %d", c.p, d, len(c.want))
+		}
+	}
+}
+
+func TestDiagonalMoves(t *testing.T) {
+	open := parseGrid("####", "#  #", "#  #", "####")
+	corner := parseGrid("####", "#  #", "## #", "####")
+	pinch := parseGrid("####", "# ##", "## #", "####")
+	from, to := Pt(1, 1), Pt(2, 2)
+
+	diagonal := Moves{Connectivity: Diagonal}
+	cutting := Moves{Connectivity: Diagonal, CutCorners: true}
+
+	for _, c := range []struct {
+		moves Moves
+		g     *Grid
+		name  string
+		want  int // path length, 0 for none
+	}{
+		{Moves{}, open, "open", 3},
+		{Moves{}, corner, "corner", 3},
+		{Moves{}, pinch, "pinch", 0},
+		{diagonal, open, "open", 2},
+		{diagonal, corner, "corner", 3},
+		{diagonal, pinch, "pinch", 0},
+		{cutting, open, "open", 2},
+		{cutting, corner, "corner", 2},
+		{cutting, pinch, "pinch", 2},
+	} {
+		path, ok := c.g.SolveWith(from, to, c.moves)
+		if got := len(path); !ok && c.want != 0 || ok && got != c.want {
+			t.Errorf("%+v: SolveWith on %s = %v, %v; want %d cells", c.moves, c.name, path, ok, c.want)
+		}
+		if got, want := c.g.IsFullyConnected(c.moves), c.want != 0; got != want {
+			t.Errorf("%+v: %s fully connected = %t, want %t", c.moves, c.name, got, want)
+		}
+	}
+
+	for m, want := range map[Moves]int{{}: 1, diagonal: 1, cutting: 2} {
+		if got := corner.Degree(from, m); got != want {
+			t.Errorf("%+v: Degree(%v) on corner = %d, want %d", m, from, got, want)
+		}
+	}
+}
+
+func sortPoints(ps []Point) []Point {
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Y < ps[j].Y || ps[i].Y == ps[j].Y && ps[i].X < ps[j].X })
+	return ps
+}
+
+func TestWallGraph(t *testing.T) {
+	g := parseGrid(
+		"###",
+		"# #",
+		"###",
+	)
+	graph := g.WallGraph()
+	if len(graph) != 8 {
+		t.Errorf("WallGraph has %d nodes, want the 8 rock cells", len(graph))
+	}
+	if _, ok := graph[Pt(1, 1)]; ok {
+		t.Error("WallGraph has a node for the carved cell")
+	}
+	for p, want := range map[Point][]Point{
+		Pt(0, 0): {Pt(1, 0), Pt(0, 1)},
+		Pt(1, 0): {Pt(0, 0), Pt(2, 0), Pt(0, 1), Pt(2, 1)},
+	} {
+		if got := sortPoints(graph[p]); !reflect.DeepEqual(got, want) {
+			t.Errorf("WallGraph()[%v] = %v, want %v", p, got, want)
+		}
+	}
+	for p, adj := range graph {
+		for _, q := range adj {
+			found := false
+			for _, r := range graph[q] {
+				found = found || r == p
+			}
+			if !found {
+				t.Errorf("%v links to %v but not back", p, q)
+			}
+		}
+	}
+}
+
+func TestChokepoints(t *testing.T) {
+	g := parseGrid(
+		"#######",
+		"#   ###",
+		"# # ###",
+		"#     #",
+		"#######",
+	)
+	want := []Point{Pt(3, 3), Pt(4, 3)}
+	if got := Chokepoints(g); !reflect.DeepEqual(got, want) {
+		t.Errorf("Chokepoints = %v, want %v", got, want)
+	}
+	if got := g.Stats().Chokepoints; got != len(want) {
+		t.Errorf("Stats().Chokepoints = %d, want %d", got, len(want))
+	}
+}
+
+func TestComponents(t *testing.T) {
+	g := parseGrid(
+		"#######",
+		"#  #  #",
+		"#  # ##",
+		"#######",
+	)
+	labels, count := g.Components(Moves{})
+	if count != 2 {
+		t.Fatalf("Components counted %d, want 2", count)
+	}
+	if len(labels) != 7 {
+		t.Errorf("Components labelled %d cells, want the 7 carved", len(labels))
+	}
+	if labels[Pt(1, 1)] != labels[Pt(2, 2)] || labels[Pt(4, 1)] != labels[Pt(4, 2)] || labels[Pt(1, 1)] == labels[Pt(5, 1)] {
+		t.Errorf("Components labels = %v, want one label per side of the wall", labels)
+	}
+	if g.IsFullyConnected(Moves{}) {
+		t.Error("IsFullyConnected with a wall between two areas")
+	}
+
+	g.SetMaterial(Pt(3, 1), Door)
+	if _, count := g.Components(Moves{}); count != 1 || !g.IsFullyConnected(Moves{}) {
+		t.Errorf("Components counted %d once the wall has a door, want 1", count)
+	}
+}
+
+func TestFlowField(t *testing.T) {
+	g, entrance, _ := mustGenerate(t, testConfig(Pt(41, 41), 23))
+	field := FlowField(g, entrance, Moves{})
+	dist := g.DistanceFrom(entrance, Moves{})
+	if len(field) != len(dist)-1 {
+		t.Errorf("FlowField has %d cells, want every reachable cell but the goal: %d", len(field), len(dist)-1)
+	}
+	if _, ok := field[entrance]; ok {
+		t.Error("FlowField points away from the goal itself")
+	}
+
+	for start := range field {
+		p := start
+		for steps := 0; p != entrance; steps++ {
+			if steps > len(dist) {
+				t.Fatalf("following the field from %v never reaches %v", start, entrance)
+			}
+			d, ok := field[p]
+			if !ok {
+				t.Fatalf("following the field from %v stops at %v", start, p)
+			}
+			p = p.AddDir(d)
+		}
+	}
+}
+
+func TestCountPaths(t *testing.T) {
+	perfect := parseGrid(
+		"#######",
+		"#<  # #",
+		"# # # #",
+		"# #  >#",
+		"#######",
+	)
+	if got := CountPaths(perfect, Pt(1, 1), Pt(5, 3), 10, Moves{}); got != 1 {
+		t.Errorf("CountPaths in a perfect maze = %d, want 1", got)
+	}
+
+	loop := parseGrid(
+		"#######",
+		"#<    #",
+		"# # # #",
+		"#    >#",
+		"#######",
+	)
+	// Down any of the three columns, or down, up and down again.
+	if got := CountPaths(loop, Pt(1, 1), Pt(5, 3), 10, Moves{}); got != 4 {
+		t.Errorf("CountPaths with two loops = %d, want 4", got)
+	}
+	if got := CountPaths(loop, Pt(1, 1), Pt(5, 3), 2, Moves{}); got != 2 {
+		t.Errorf("CountPaths limited to 2 = %d, want 2", got)
+	}
+
+	open := parseGrid("####", "#  #", "#  #", "####")
+	pinch := parseGrid("####", "# ##", "## #", "####")
+	for _, c := range []struct {
+		g     *Grid
+		name  string
+		moves Moves
+		want  int
+	}{
+		{open, "open", Moves{}, 2},
+		// Straight across, or by way of one or both other cells.
+		{open, "open", Moves{Connectivity: Diagonal}, 5},
+		{pinch, "pinch", Moves{}, 0},
+		{pinch, "pinch", Moves{Connectivity: Diagonal}, 0},
+		{pinch, "pinch", Moves{Connectivity: Diagonal, CutCorners: true}, 1},
+	} {
+		if got := CountPaths(c.g, Pt(1, 1), Pt(2, 2), 10, c.moves); got != c.want {
+			t.Errorf("%+v: CountPaths on %s = %d, want %d", c.moves, c.name, got, c.want)
+		}
+	}
+}
+
+func TestEntranceConnected(t *testing.T) {
+	g, entrance, exit := mustGenerate(t, testConfig(Pt(31, 31), 25))
+	if !g.EntranceConnected(entrance, exit) {
+		t.Error("generated maze's exit is not reachable from its entrance")
+	}
+
+	cut := parseGrid(
+		"#######",
+		"#<  # #",
+		"# # #>#",
+		"#######",
+	)
+	if cut.EntranceConnected(Pt(1, 1), Pt(5, 2)) {
+		t.Error("EntranceConnected through a wall")
+	}
+}
+
+func TestVertexConnectivity(t *testing.T) {
+	cfg := testConfig(Pt(41, 41), 27)
+	cfg.Rooms.Tries = 0
+	perfect, _, _ := mustGenerate(t, cfg)
+	if got := VertexConnectivity(perfect); got != 1 {
+		t.Errorf("VertexConnectivity of a perfect maze = %d, want 1", got)
+	}
+
+	braided := parseGrid(
+		"#######",
+		"#     #",
+		"# # # #",
+		"#     #",
+		"#######",
+	)
+	if got := VertexConnectivity(braided); got != 2 {
+		t.Errorf("VertexConnectivity of a braided maze = %d, want 2", got)
+	}
+}
+
+func TestGirth(t *testing.T) {
+	cfg := testConfig(Pt(41, 41), 29)
+	cfg.Rooms.Tries = 0
+	perfect, _, _ := mustGenerate(t, cfg)
+	if got := Girth(perfect); got != -1 {
+		t.Errorf("Girth of a perfect maze = %d, want -1", got)
+	}
+
+	loop := parseGrid(
+		"#########",
+		"#   #   #",
+		"# # #####",
+		"#       #",
+		"#########",
+	)
+	if got := Girth(loop); got != 8 {
+		t.Errorf("Girth with one 8-cell loop = %d, want 8", got)
+	}
+}
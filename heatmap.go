@@ -0,0 +1,58 @@
+package maze
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+)
+
+// Color stops of the heatmap gradient, nearest first.
+var heatStops = []color.RGBA{
+	{20, 10, 60, 255},
+	{140, 20, 120, 255},
+	{240, 110, 30, 255},
+	{255, 250, 180, 255},
+}
+
+// RenderHeatmap colors every cell reachable from origin by its distance from
+// it, opts.Scale pixels square per cell, from dark for the nearest cells to
+// bright for the farthest. Walls keep their material colors, and passable
+// cells out of reach are drawn in the theme's Rock.
+func (g *Grid) RenderHeatmap(w io.Writer, origin Point, opts RenderOptions) error {
+	scale, theme := opts.scale(1), opts.theme()
+	img := image.NewRGBA(scaled(g.Bounds(), scale))
+	dist := g.DistanceFrom(origin, Moves{})
+	far := 0
+	for _, d := range dist {
+		far = max(far, d)
+	}
+
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			c := theme.material(g.At(Pt(x, y)), opts.RevealSecrets)
+			if d, ok := dist[Pt(x, y)]; ok {
+				c = heatColor(float64(d) / float64(max(far, 1)))
+			} else if passable(g.At(Pt(x, y))) {
+				c = theme.Rock
+			}
+			cell := image.Rect(x*scale, y*scale, (x+1)*scale, (y+1)*scale)
+			draw.Draw(img, cell, image.NewUniform(c), image.Point{}, draw.Src)
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// heatColor interpolates the heatmap gradient at t in [0, 1].
+func heatColor(t float64) color.Color {
+	pos := t * float64(len(heatStops)-1)
+	i := min(int(pos), len(heatStops)-2)
+	f := pos - float64(i)
+	a, b := heatStops[i], heatStops[i+1]
+	mix := func(u, v uint8) uint8 {
+		return uint8(float64(u) + (float64(v)-float64(u))*f)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
@@ -0,0 +1,403 @@
+package maze
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"math/rand"
+)
+
+// Hex addresses a cell of a HexGrid in axial coordinates: Q counts cells
+// along a row and R counts rows, so that moving down a row and Q-1 stays in
+// the same diagonal.
+type Hex struct{ Q, R int }
+
+func (h Hex) Add(o Hex) Hex {
+	return Hex{h.Q + o.Q, h.R + o.R}
+}
+
+func (h Hex) Mul(i int) Hex {
+	return Hex{h.Q * i, h.R * i}
+}
+
+// HexDirs are the six neighbours of a hex, counterclockwise from the right.
+var HexDirs = []Hex{{1, 0}, {1, -1}, {0, -1}, {-1, 0}, {-1, 1}, {0, 1}}
+
+// HexGrid is a maze of pointy-top hexagonal cells in Size.Y rows of Size.X,
+// with every odd row shifted half a cell to the right. As in Grid, walls are
+// whole cells: corridors run between node cells, through the one cell between
+// each pair of them. The node cells are those with both axial coordinates even,
+// less every third of them, so that they form a honeycomb: no two corridors
+// leaving a node are ever next to each other.
+type HexGrid struct {
+	g        []Material
+	Size     Point
+	regions  []Region
+	regCount Region
+}
+
+func newHexGrid(size Point) *HexGrid {
+	return &HexGrid{
+		make([]Material, size.X*size.Y),
+		size,
+		make([]Region, size.X*size.Y),
+		0,
+	}
+}
+
+// offset returns the column and row of h.
+func (g *HexGrid) offset(h Hex) Point {
+	return Pt(h.Q+(h.R-(h.R&1))/2, h.R)
+}
+
+// hexAt returns the cell in column p.X of row p.Y.
+func (g *HexGrid) hexAt(p Point) Hex {
+	return Hex{p.X - (p.Y-(p.Y&1))/2, p.Y}
+}
+
+func (g *HexGrid) InBounds(h Hex) bool {
+	p := g.offset(h)
+	return p.X >= 0 && p.Y >= 0 && p.X < g.Size.X && p.Y < g.Size.Y
+}
+
+func (g *HexGrid) At(h Hex) Material {
+	if !g.InBounds(h) {
+		return Rock
+	}
+	p := g.offset(h)
+	return g.g[p.Y*g.Size.X+p.X]
+}
+
+func (g *HexGrid) RegionAt(h Hex) Region {
+	if !g.InBounds(h) {
+		return NoRegion
+	}
+	p := g.offset(h)
+	return g.regions[p.Y*g.Size.X+p.X]
+}
+
+func (g *HexGrid) SetMaterial(h Hex, m Material) {
+	g.mustBeInBounds(h)
+	p := g.offset(h)
+	g.g[p.Y*g.Size.X+p.X] = m
+}
+
+func (g *HexGrid) SetRegion(h Hex, r Region) {
+	g.mustBeInBounds(h)
+	p := g.offset(h)
+	g.regions[p.Y*g.Size.X+p.X] = r
+}
+
+func (g *HexGrid) NewRegion() Region {
+	g.regCount++
+	return g.regCount
+}
+
+func (g *HexGrid) mustBeInBounds(h Hex) {
+	if !g.InBounds(h) {
+		panic(fmt.Sprintf("maze: hex %v outside %dx%d grid", h, g.Size.X, g.Size.Y))
+	}
+}
+
+// node reports whether corridors branch at h rather than pass through it.
+func (h Hex) node() bool {
+	return h.Q%2 == 0 && h.R%2 == 0 && ((h.Q-h.R)/2%3+3)%3 != 2
+}
+
+// GenerateHex builds a hex maze of size columns and rows: corridors are grown
+// with the growing tree algorithm from every node cell not yet reached, the
+// resulting regions are joined into one through randomly chosen connectors,
+// and an Entrance and an Exit are placed as far apart as the maze allows. The
+// same seed always yields the same maze. onStep and ctx work as in Generate.
+// The grid must be at least 3x3.
+func GenerateHex(ctx context.Context, size Point, seed int64, onStep func(*HexGrid)) (grid *HexGrid, entrance, exit Hex, err error) {
+	if size.X < 3 || size.Y < 3 {
+		return nil, entrance, exit, fmt.Errorf("maze: hex maze needs at least 3x3 cells, got %dx%d", size.X, size.Y)
+	}
+	rng := rand.New(rand.NewSource(seed))
+	grid = newHexGrid(size)
+
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			if h := grid.hexAt(Pt(x, y)); h.node() && grid.At(h) == Rock {
+				if err := growHex(ctx, grid, h, grid.NewRegion(), rng, onStep); err != nil {
+					return nil, entrance, exit, err
+				}
+			}
+		}
+	}
+	if err := connectHexRegions(ctx, grid, rng, onStep); err != nil {
+		return nil, entrance, exit, err
+	}
+
+	start, ok := grid.firstPassable()
+	if !ok {
+		return grid, start, start, nil
+	}
+	entrance = grid.farthest(start)
+	exit = grid.farthest(entrance)
+	grid.SetMaterial(entrance, Entrance)
+	grid.SetMaterial(exit, Exit)
+	return grid, entrance, exit, nil
+}
+
+// canCarveHex reports whether a corridor can be carved from the node cell h to
+// the next node in direction d. The cell between them must touch no passage
+// but h, or corridors would meet sideways.
+func canCarveHex(grid *HexGrid, h Hex, d Hex) bool {
+	next := h.Add(d.Mul(2))
+	return next.node() && grid.InBounds(h.Add(d)) && grid.InBounds(next) && grid.At(next) == Rock &&
+		grid.touchesOnly(h.Add(d), h, next)
+}
+
+// touchesOnly reports whether every passable cell next to h is one of open.
+func (g *HexGrid) touchesOnly(h Hex, open ...Hex) bool {
+Neighbours:
+	for _, d := range HexDirs {
+		n := h.Add(d)
+		if !passable(g.At(n)) {
+			continue
+		}
+		for _, o := range open {
+			if n == o {
+				continue Neighbours
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// growHex carves a tree of corridors from the node cell from, like grow with
+// the GrowingTree algorithm.
+func growHex(ctx context.Context, grid *HexGrid, from Hex, region Region, rng *rand.Rand, onStep func(*HexGrid)) error {
+	cells := []Hex{from}
+	grid.SetMaterial(from, Carved)
+	grid.SetRegion(from, region)
+	if onStep != nil {
+		onStep(grid)
+	}
+
+	for len(cells) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		at := rng.Intn(len(cells))
+		cell := cells[at]
+		unmade := make([]Hex, 0, len(HexDirs))
+		for _, d := range HexDirs {
+			if canCarveHex(grid, cell, d) {
+				unmade = append(unmade, d)
+			}
+		}
+
+		if len(unmade) == 0 {
+			cells[at] = cells[len(cells)-1]
+			cells = cells[:len(cells)-1]
+			continue
+		}
+		d := unmade[rng.Intn(len(unmade))]
+		for _, h := range []Hex{cell.Add(d), cell.Add(d.Mul(2))} {
+			grid.SetMaterial(h, Carved)
+			grid.SetRegion(h, region)
+		}
+		cells = append(cells, cell.Add(d.Mul(2)))
+		if onStep != nil {
+			onStep(grid)
+		}
+	}
+	return nil
+}
+
+// connectHexRegions joins the regions into one with joinRegions, through the
+// rock cells between node cells of different regions. A cell is only opened
+// if it touches no passage but the two it joins, so that the maze stays a
+// tree. Whatever then stays apart from the largest region, such as a node cut
+// off in a corner, is filled back in.
+func connectHexRegions(ctx context.Context, grid *HexGrid, rng *rand.Rand, onStep func(*HexGrid)) error {
+	type connector struct{ loc, a, b Hex }
+	conns := make([]connector, 0)
+	for y := 0; y < grid.Size.Y; y++ {
+		for x := 0; x < grid.Size.X; x++ {
+			h := grid.hexAt(Pt(x, y))
+			if !h.node() || grid.At(h) == Rock {
+				continue
+			}
+			for _, d := range HexDirs[:3] {
+				loc, there := h.Add(d), h.Add(d.Mul(2))
+				if there.node() && grid.InBounds(loc) && grid.At(loc) == Rock && grid.At(there) != Rock && grid.RegionAt(there) != grid.RegionAt(h) {
+					conns = append(conns, connector{loc, h, there})
+				}
+			}
+		}
+	}
+	main, sets, err := joinRegions(ctx, conns, grid.regCount, rng,
+		func(c connector) (Region, Region) { return grid.RegionAt(c.a), grid.RegionAt(c.b) },
+		func(c connector) Hex { return c.loc },
+		nil,
+		func(c connector, main Region) bool {
+			if !grid.touchesOnly(c.loc, c.a, c.b) {
+				return false
+			}
+			grid.SetMaterial(c.loc, Door)
+			grid.SetRegion(c.loc, main)
+			if onStep != nil {
+				onStep(grid)
+			}
+			return true
+		},
+		func(connector, Region) {})
+	if err != nil {
+		return err
+	}
+
+	size := make(map[Region]int)
+	for _, r := range grid.regions {
+		if r != NoRegion {
+			size[sets.find(r)]++
+		}
+	}
+	for r, n := range size {
+		if n > size[main] || n == size[main] && r < main {
+			main = r
+		}
+	}
+	for i, r := range grid.regions {
+		if r == NoRegion {
+			continue
+		}
+		if r = sets.find(r); r != main {
+			grid.g[i], r = Rock, NoRegion
+		}
+		grid.regions[i] = r
+	}
+	return nil
+}
+
+// passages returns the passable cells next to h.
+func (g *HexGrid) passages(h Hex) []Hex {
+	hs := make([]Hex, 0, len(HexDirs))
+	for _, d := range HexDirs {
+		if n := h.Add(d); passable(g.At(n)) {
+			hs = append(hs, n)
+		}
+	}
+	return hs
+}
+
+// firstPassable returns the first passable cell in row-major order.
+func (g *HexGrid) firstPassable() (Hex, bool) {
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if h := g.hexAt(Pt(x, y)); passable(g.At(h)) {
+				return h, true
+			}
+		}
+	}
+	return Hex{}, false
+}
+
+// farthest returns the reachable cell farthest from `from`, breaking ties by
+// row-major order.
+func (g *HexGrid) farthest(from Hex) Hex {
+	best, far := from, 0
+	for h, d := range distances(from, g.passages) {
+		p, q := g.offset(h), g.offset(best)
+		if d > far || d == far && (p.Y < q.Y || p.Y == q.Y && p.X < q.X) {
+			best, far = h, d
+		}
+	}
+	return best
+}
+
+// Solve finds a shortest path from start to goal through passable cells by
+// breadth-first search, as Grid.Solve does.
+func (g *HexGrid) Solve(start, goal Hex) (path []Hex, ok bool) {
+	if !passable(g.At(start)) || !passable(g.At(goal)) {
+		return nil, false
+	}
+	return shortestPath(start, goal, g.passages, nil)
+}
+
+// center returns the pixel center of h when hexes are drawn size pixels from
+// center to corner.
+func (g *HexGrid) center(h Hex, size float64) (x, y float64) {
+	p := g.offset(h)
+	return size * math.Sqrt(3) * (float64(p.X) + 0.5 + 0.5*float64(p.Y&1)), size * (1 + 1.5*float64(p.Y))
+}
+
+// hexBounds returns the size of the image the grid is drawn in.
+func (g *HexGrid) hexBounds(size float64) image.Rectangle {
+	return image.Rect(0, 0, int(math.Ceil(size*math.Sqrt(3)*(float64(g.Size.X)+0.5))), int(math.Ceil(size*(1.5*float64(g.Size.Y)+0.5))))
+}
+
+// RenderMaterials writes a PNG with every cell a hexagon opts.Scale pixels
+// from center to corner in its material's theme color. Pixels outside the
+// grid are transparent.
+func (g *HexGrid) RenderMaterials(w io.Writer, opts RenderOptions) error {
+	s, theme := float64(opts.scale(1)), opts.theme()
+	img := image.NewRGBA(g.hexBounds(s))
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if h := g.hexAtPixel(float64(x)+0.5, float64(y)+0.5, s); g.InBounds(h) {
+				img.Set(x, y, theme.material(g.At(h), opts.RevealSecrets))
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// hexAtPixel returns the hex covering pixel (x, y), by converting it to
+// fractional cube coordinates and rounding.
+func (g *HexGrid) hexAtPixel(x, y, size float64) Hex {
+	x -= size * math.Sqrt(3) / 2
+	y -= size
+	q := (math.Sqrt(3)/3*x - y/3) / size
+	r := 2 * y / 3 / size
+	s := -q - r
+
+	rq, rr, rs := math.Round(q), math.Round(r), math.Round(s)
+	dq, dr, ds := math.Abs(rq-q), math.Abs(rr-r), math.Abs(rs-s)
+	if dq > dr && dq > ds {
+		rq = -rr - rs
+	} else if dr > ds {
+		rr = -rq - rs
+	}
+	return Hex{int(rq), int(rr)}
+}
+
+// RenderSVG draws the grid as an SVG image with every cell a hexagon
+// opts.Scale units from center to corner, or SVG_CELL_SIZE if unset, filled in
+// its material's theme color.
+func (g *HexGrid) RenderSVG(w io.Writer, opts RenderOptions) error {
+	s, theme := float64(opts.scale(SVG_CELL_SIZE)), opts.theme()
+	b := g.hexBounds(s)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d">`+"\n",
+		b.Dx(), b.Dy(), b.Dx(), b.Dy())
+
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			h := g.hexAt(Pt(x, y))
+			cx, cy := g.center(h, s)
+			fmt.Fprintf(bw, `<polygon fill="%s" points="`, hexColor(theme.material(g.At(h), opts.RevealSecrets)))
+			for i := 0; i < 6; i++ {
+				a := math.Pi / 180 * float64(60*i-30)
+				if i > 0 {
+					bw.WriteByte(' ')
+				}
+				fmt.Fprintf(bw, "%.1f,%.1f", cx+s*math.Cos(a), cy+s*math.Sin(a))
+			}
+			fmt.Fprintf(bw, "\"/>\n")
+		}
+	}
+	fmt.Fprintf(bw, "</svg>\n")
+
+	return bw.Flush()
+}
@@ -0,0 +1,67 @@
+package maze
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestGenerateHex(t *testing.T) {
+	size := Pt(21, 15)
+	g, entrance, exit, err := GenerateHex(context.Background(), size, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.At(entrance) != Entrance || g.At(exit) != Exit || entrance == exit {
+		t.Errorf("entrance %v and exit %v not placed", entrance, exit)
+	}
+
+	reached := map[Hex]bool{entrance: true}
+	queue := []Hex{entrance}
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+		for _, n := range g.passages(h) {
+			if !reached[n] {
+				reached[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	// A perfect maze is a tree: one passage fewer than it has cells, so no
+	// corridors touching sideways.
+	cells, passages := 0, 0
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			h := g.hexAt(Pt(x, y))
+			if h.node() && !passable(g.At(h)) {
+				t.Errorf("node cell %v not carved", h)
+			}
+			if passable(g.At(h)) {
+				cells++
+				passages += len(g.passages(h))
+				if !reached[h] {
+					t.Errorf("cell %v unreachable from the entrance", h)
+				}
+			}
+			if cx, cy := g.center(h, 10); g.hexAtPixel(cx, cy, 10) != h {
+				t.Errorf("pixel at the center of %v maps to %v", h, g.hexAtPixel(cx, cy, 10))
+			}
+		}
+	}
+	if passages/2 != cells-1 {
+		t.Errorf("maze of %d cells has %d passages, want %d", cells, passages/2, cells-1)
+	}
+	if path, ok := g.Solve(entrance, exit); !ok || path[0] != entrance || path[len(path)-1] != exit {
+		t.Errorf("Solve(%v, %v) = %v, %t", entrance, exit, path, ok)
+	}
+
+	again, _, _, err := GenerateHex(context.Background(), size, 3, nil)
+	if err != nil || !reflect.DeepEqual(again.g, g.g) {
+		t.Errorf("GenerateHex differs for the same seed")
+	}
+
+	if _, _, _, err := GenerateHex(context.Background(), Pt(2, 5), 3, nil); err == nil {
+		t.Errorf("GenerateHex of a 2x5 grid succeeded")
+	}
+}
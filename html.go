@@ -0,0 +1,90 @@
+package maze
+
+import (
+	"html/template"
+	"io"
+)
+
+const HTML_CELL_SIZE = 10
+
+var htmlPage = template.Must(template.New("maze").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Maze</title>
+<style>
+body { margin: 2em; font-family: sans-serif; }
+canvas { cursor: crosshair; }
+</style>
+</head>
+<body>
+<canvas id="maze"></canvas>
+<p>Click carved cells next to the end of your path to extend it; click an earlier cell to back up.</p>
+<script>
+var maze = {{.}};
+var canvas = document.getElementById("maze");
+var ctx = canvas.getContext("2d");
+var path = [];
+
+canvas.width = maze.Width * maze.CellSize;
+canvas.height = maze.Height * maze.CellSize;
+
+function carved(x, y) {
+	return x >= 0 && y >= 0 && x < maze.Width && y < maze.Height &&
+		maze.Open[y * maze.Width + x];
+}
+
+function fill(x, y, color) {
+	ctx.fillStyle = color;
+	ctx.fillRect(x * maze.CellSize, y * maze.CellSize, maze.CellSize, maze.CellSize);
+}
+
+function draw() {
+	for (var y = 0; y < maze.Height; y++) {
+		for (var x = 0; x < maze.Width; x++) {
+			fill(x, y, carved(x, y) ? "#fff" : "#000");
+		}
+	}
+	path.forEach(function(p) { fill(p[0], p[1], "#e33"); });
+}
+
+canvas.addEventListener("click", function(e) {
+	var r = canvas.getBoundingClientRect();
+	var x = Math.floor((e.clientX - r.left) / maze.CellSize);
+	var y = Math.floor((e.clientY - r.top) / maze.CellSize);
+	if (!carved(x, y)) {
+		return;
+	}
+	for (var i = 0; i < path.length; i++) {
+		if (path[i][0] == x && path[i][1] == y) {
+			path = path.slice(0, i + 1);
+			draw();
+			return;
+		}
+	}
+	var last = path[path.length - 1];
+	if (!last || Math.abs(last[0] - x) + Math.abs(last[1] - y) == 1) {
+		path.push([x, y]);
+		draw();
+	}
+});
+
+draw();
+</script>
+</body>
+</html>
+`))
+
+// WriteHTML writes a standalone HTML page that draws the maze on a canvas and
+// lets the viewer trace a path through it. The grid is inlined as JSON.
+func (g *Grid) WriteHTML(w io.Writer) error {
+	open := make([]bool, len(g.g))
+	for i, m := range g.g {
+		open[i] = passable(m)
+	}
+
+	return htmlPage.Execute(w, struct {
+		Width, Height, CellSize int
+		Open                    []bool
+	}{g.Size.X, g.Size.Y, HTML_CELL_SIZE, open})
+}
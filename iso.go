@@ -0,0 +1,82 @@
+package maze
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// Tile height, in pixels, of isometric output unless RenderOptions.Scale is
+// set.
+const ISO_CELL_SIZE = 16
+
+// RenderIso draws the grid in isometric projection: carved cells as flat floor
+// tiles and rock cells as cubes wallHeight pixels tall. Each tile is a diamond
+// 2*opts.Scale pixels wide and opts.Scale tall, or ISO_CELL_SIZE tall if
+// unset. Cells are painted back to
+// front so nearer cubes occlude farther ones.
+func (g *Grid) RenderIso(w io.Writer, wallHeight int, opts RenderOptions) error {
+	c, theme := opts.scale(ISO_CELL_SIZE), opts.theme()
+	img := image.NewRGBA(image.Rect(0, 0, (g.Size.X+g.Size.Y)*c, (g.Size.X+g.Size.Y)*c/2+wallHeight))
+	ox, oy := g.Size.Y*c, wallHeight
+
+	for s := 0; s <= g.Size.X+g.Size.Y-2; s++ {
+		for x := max(0, s-g.Size.Y+1); x <= s && x < g.Size.X; x++ {
+			y := s - x
+			col := theme.material(g.At(Pt(x, y)), opts.RevealSecrets)
+			sx, sy := ox+(x-y)*c, oy+(x+y)*c/2
+
+			top := image.Pt(sx, sy)
+			right := image.Pt(sx+c, sy+c/2)
+			bottom := image.Pt(sx, sy+c)
+			left := image.Pt(sx-c, sy+c/2)
+
+			if passable(g.At(Pt(x, y))) {
+				fillConvex(img, col, top, right, bottom, left)
+				continue
+			}
+
+			up := image.Pt(0, -wallHeight)
+			fillConvex(img, shade(col, 0.2), left.Add(up), bottom.Add(up), bottom, left)
+			fillConvex(img, col, bottom.Add(up), right.Add(up), right, bottom)
+			fillConvex(img, shade(col, 0.35), top.Add(up), right.Add(up), bottom.Add(up), left.Add(up))
+		}
+	}
+
+	return png.Encode(w, img)
+}
+
+// shade mixes c toward white by f, so that even black cubes show their faces.
+func shade(c color.Color, f float64) color.Color {
+	r, g, b, a := c.RGBA()
+	mix := func(v uint32) uint8 {
+		return uint8((float64(v>>8)*(1-f) + 255*f))
+	}
+	return color.RGBA{mix(r), mix(g), mix(b), uint8(a >> 8)}
+}
+
+// fillConvex fills the convex polygon with vertices given in clockwise screen
+// order, testing each pixel center in its bounding box.
+func fillConvex(img *image.RGBA, c color.Color, pts ...image.Point) {
+	box := image.Rectangle{pts[0], pts[0].Add(image.Pt(1, 1))}
+	for _, p := range pts[1:] {
+		box = box.Union(image.Rectangle{p, p.Add(image.Pt(1, 1))})
+	}
+	box = box.Intersect(img.Bounds())
+
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+	Pixels:
+		for x := box.Min.X; x < box.Max.X; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			for i, a := range pts {
+				b := pts[(i+1)%len(pts)]
+				cross := float64(b.X-a.X)*(py-float64(a.Y)) - float64(b.Y-a.Y)*(px-float64(a.X))
+				if cross < 0 {
+					continue Pixels
+				}
+			}
+			img.Set(x, y, c)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package maze
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestRenderIso(t *testing.T) {
+	g := parseGrid(
+		"###",
+		"# #",
+		"###",
+	)
+	var buf bytes.Buffer
+	if err := g.RenderIso(&buf, 8, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, h := img.Bounds().Dx(), img.Bounds().Dy(); w != 6*ISO_CELL_SIZE || h != 3*ISO_CELL_SIZE+8 {
+		t.Errorf("image is %dx%d, want %dx%d", w, h, 6*ISO_CELL_SIZE, 3*ISO_CELL_SIZE+8)
+	}
+
+	// The back of the middle cell's floor tile shows between the cubes
+	// around it.
+	want := color.RGBAModel.Convert(DefaultTheme().material(Carved, false))
+	x, y := 3*ISO_CELL_SIZE, 8+ISO_CELL_SIZE+ISO_CELL_SIZE/4
+	if got := color.RGBAModel.Convert(img.At(x, y)); got != want {
+		t.Errorf("floor tile drawn %v at (%d, %d), want %v", got, x, y, want)
+	}
+}
@@ -0,0 +1,58 @@
+package maze
+
+import (
+	"image/draw"
+	"image/png"
+	"io"
+	"sort"
+)
+
+// Isochrone groups the cells reachable from `from` by travel distance. Each
+// cell goes into the smallest band b with distance <= b; cells farther than
+// the largest band are left out. Cells in each band are in row-major order.
+func Isochrone(g *Grid, from Point, bands []int) map[int][]Point {
+	sorted := append([]int{}, bands...)
+	sort.Ints(sorted)
+
+	dist := g.DistanceFrom(from, Moves{})
+	iso := make(map[int][]Point)
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			d, ok := dist[Pt(x, y)]
+			if !ok {
+				continue
+			}
+			if i := sort.SearchInts(sorted, d); i < len(sorted) {
+				iso[sorted[i]] = append(iso[sorted[i]], Pt(x, y))
+			}
+		}
+	}
+
+	return iso
+}
+
+// RenderIsochrone writes a PNG of the grid in its material colors, opts.Scale
+// pixels square per cell, with every band of Isochrone(g, from, bands) drawn
+// over it in a color of its own.
+func (g *Grid) RenderIsochrone(w io.Writer, from Point, bands []int, opts RenderOptions) error {
+	scale := opts.scale(1)
+	img := materialsRGBA(g, opts)
+	renderIsochrone(img, Isochrone(g, from, bands), scale)
+	return png.Encode(w, img)
+}
+
+// renderIsochrone colors each band of an isochrone map differently, nearest
+// band first.
+func renderIsochrone(img draw.Image, iso map[int][]Point, scale int) {
+	bands := make([]int, 0, len(iso))
+	for b := range iso {
+		bands = append(bands, b)
+	}
+	sort.Ints(bands)
+
+	for i, b := range bands {
+		for _, p := range iso[b] {
+			fillCell(img, p, scale, depthColor(i))
+		}
+	}
+}
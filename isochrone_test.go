@@ -0,0 +1,47 @@
+package maze
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+func TestIsochrone(t *testing.T) {
+	g := parseGrid(
+		"#########",
+		"#       #",
+		"##### ###",
+		"#########",
+	)
+	from := Pt(1, 1)
+	iso := Isochrone(g, from, []int{5, 2})
+	want := map[int][]Point{
+		2: {Pt(1, 1), Pt(2, 1), Pt(3, 1)},
+		5: {Pt(4, 1), Pt(5, 1), Pt(6, 1), Pt(5, 2)},
+	}
+	if !reflect.DeepEqual(iso, want) {
+		t.Errorf("Isochrone = %v, want %v", iso, want)
+	}
+
+	var buf bytes.Buffer
+	if err := g.RenderIsochrone(&buf, from, []int{2, 5}, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	near, far, beyond := img.At(1, 1), img.At(4, 1), img.At(7, 1)
+	if near == far || near == beyond || far == beyond {
+		t.Errorf("bands drawn %v, %v and unbanded %v; want three distinct colors", near, far, beyond)
+	}
+	for band, c := range map[int]color.Color{2: near, 5: far} {
+		for _, p := range want[band] {
+			if img.At(p.X, p.Y) != c {
+				t.Errorf("cell %v of band %d drawn %v, want %v", p, band, img.At(p.X, p.Y), c)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+)
+
+// GenerateLevels builds a stack of n mazes, each made by Generate from cfg but
+// with its own seed drawn from cfg.Seed, and joins every consecutive pair with one
+// staircase: a StairsDown on level k and a StairsUp on level k+1 at the same
+// cell, chosen at random among the cells carved on both. Since every level is
+// fully connected, the stairs are reachable from anywhere on their level.
+// n must be at least 1.
+func GenerateLevels(ctx context.Context, n int, cfg Config) ([]*Grid, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("maze: need at least 1 level, got %d", n)
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	size := cfg.Size
+	levels := make([]*Grid, n)
+
+	for k := range levels {
+		cfg.Seed = rng.Int63()
+		level, _, _, err := Generate(ctx, cfg, nil)
+		if err != nil {
+			return nil, err
+		}
+		levels[k] = level
+	}
+
+	for k := 0; k+1 < n; k++ {
+		upper, lower := levels[k], levels[k+1]
+		both := make([]Point, 0)
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				if p := Pt(x, y); upper.At(p) == Carved && lower.At(p) == Carved {
+					both = append(both, p)
+				}
+			}
+		}
+		if len(both) == 0 {
+			return nil, fmt.Errorf("maze: no cell carved on both level %d and level %d", k, k+1)
+		}
+
+		stairs := both[rng.Intn(len(both))]
+		upper.SetMaterial(stairs, StairsDown)
+		lower.SetMaterial(stairs, StairsUp)
+	}
+
+	return levels, nil
+}
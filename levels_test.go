@@ -0,0 +1,56 @@
+package maze
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGenerateLevels(t *testing.T) {
+	levels, err := GenerateLevels(context.Background(), 4, testConfig(Pt(31, 21), 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(levels) != 4 {
+		t.Fatalf("GenerateLevels(4) returned %d levels", len(levels))
+	}
+	for _, n := range []int{0, -1} {
+		if _, err := GenerateLevels(context.Background(), n, testConfig(Pt(31, 21), 7)); err == nil {
+			t.Errorf("GenerateLevels(%d) succeeded", n)
+		}
+	}
+
+	find := func(g *Grid, m Material) []Point {
+		found := make([]Point, 0)
+		for y := 0; y < g.Size.Y; y++ {
+			for x := 0; x < g.Size.X; x++ {
+				if g.At(Pt(x, y)) == m {
+					found = append(found, Pt(x, y))
+				}
+			}
+		}
+		return found
+	}
+
+	for k, g := range levels {
+		down, up := find(g, StairsDown), find(g, StairsUp)
+		wantDown, wantUp := 1, 1
+		if k == 0 {
+			wantUp = 0
+		}
+		if k == len(levels)-1 {
+			wantDown = 0
+		}
+		if len(down) != wantDown || len(up) != wantUp {
+			t.Errorf("level %d has stairs down at %v and up at %v", k, down, up)
+			continue
+		}
+		if len(down) == 1 {
+			if levels[k+1].At(down[0]) != StairsUp {
+				t.Errorf("stairs down on level %d at %v have no stairs up below", k, down[0])
+			}
+			if _, ok := g.Solve(find(g, Entrance)[0], down[0]); !ok {
+				t.Errorf("stairs down on level %d at %v are unreachable", k, down[0])
+			}
+		}
+	}
+}
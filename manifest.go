@@ -0,0 +1,54 @@
+package maze
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Version of the manifest format WriteManifest writes. It goes up whenever a
+// field is removed or changes meaning; new fields may be added without it.
+const MANIFEST_VERSION = 1
+
+// manifestJSON is the manifest WriteManifest writes. Mask and Density can't be
+// written out, so it only records whether they were set.
+type manifestJSON struct {
+	Version        int
+	Seed           int64
+	Config         Config
+	Mask, Density  bool
+	Stats          Stats
+	Entrance, Exit *Point
+	Files          []string
+}
+
+// WriteManifest writes a JSON description of g as generated from cfg: the
+// manifest version, seed and config, g's Stats, its entrance and exit, which
+// are null if it has none, and the paths of any files it was written to.
+// Together with the seed and config it is enough to generate g again, unless
+// cfg has a Mask or Density.
+func WriteManifest(w io.Writer, g *Grid, cfg Config, files ...string) error {
+	m := manifestJSON{
+		Version: MANIFEST_VERSION,
+		Seed:    cfg.Seed,
+		Config:  cfg,
+		Mask:    cfg.Mask != nil,
+		Density: cfg.Density != nil,
+		Stats:   g.Stats(),
+		Files:   append(make([]string, 0), files...),
+	}
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			p := Pt(x, y)
+			switch g.At(p) {
+			case Entrance:
+				m.Entrance = &p
+			case Exit:
+				m.Exit = &p
+			}
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(m)
+}
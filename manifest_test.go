@@ -0,0 +1,54 @@
+package maze
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestWriteManifest(t *testing.T) {
+	cfg := testConfig(Pt(31, 21), 23)
+	cfg.ExtraConnectors = 2
+	g, entrance, exit := mustGenerate(t, cfg)
+
+	var buf bytes.Buffer
+	if err := WriteManifest(&buf, g, cfg, "maze.png", "thumb.png"); err != nil {
+		t.Fatal(err)
+	}
+	var m manifestJSON
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != MANIFEST_VERSION || m.Seed != cfg.Seed || m.Mask || m.Density {
+		t.Errorf("manifest has version %d, seed %d, mask %t and density %t", m.Version, m.Seed, m.Mask, m.Density)
+	}
+	if m.Stats != g.Stats() {
+		t.Errorf("manifest stats %+v, want %+v", m.Stats, g.Stats())
+	}
+	if m.Entrance == nil || *m.Entrance != entrance || m.Exit == nil || *m.Exit != exit {
+		t.Errorf("manifest has entrance %v and exit %v, want %v and %v", m.Entrance, m.Exit, entrance, exit)
+	}
+	if !reflect.DeepEqual(m.Files, []string{"maze.png", "thumb.png"}) {
+		t.Errorf("manifest files %q", m.Files)
+	}
+
+	// The config read back generates the same maze.
+	again, _, _ := mustGenerate(t, m.Config)
+	if !sameMaze(again, g) {
+		t.Error("maze generated from the manifest's config differs")
+	}
+
+	cfg.Mask = func(p Point) bool { return p.X < 20 }
+	g, _, _ = mustGenerate(t, cfg)
+	buf.Reset()
+	if err := WriteManifest(&buf, g, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Mask || len(m.Files) != 0 {
+		t.Errorf("manifest of a masked maze has mask %t and files %q", m.Mask, m.Files)
+	}
+}
@@ -0,0 +1,1547 @@
+// Package maze generates room-and-corridor mazes on a grid of cells.
+package maze
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Default for RoomParams.Tries.
+const ROOM_TRIES = 10
+
+// Chance that a connector made redundant by a merge is opened anyway,
+// adding a loop to the maze.
+const EXTRA_CONNECTOR_CHANCE = 0.05
+
+type Material int
+type Region int
+type Placement int
+type Algorithm int
+type Shape int
+type ExitPlacement int
+
+// NoRegion is the region of every cell not carved into a region, and of
+// cells outside the grid.
+const NoRegion Region = 0
+
+const (
+	Uniform Placement = iota
+	PoissonDisk
+)
+
+// Outlines of the maze. Rectangle uses the whole grid; Circle only the cells
+// inside the circle inscribed in it; Polygon only the cells inside
+// Config.Polygon.
+const (
+	Rectangle Shape = iota
+	Circle
+	Polygon
+)
+
+// Corridor carving algorithms. GrowingTree extends a random cell of the
+// frontier each step; Backtracker always extends the newest one and backs up
+// when it is stuck, giving long, winding passages with few branches.
+// Unicursal carves one corridor without branches through the whole maze, from
+// the entrance to the exit; it places no rooms.
+const (
+	GrowingTree Algorithm = iota
+	Backtracker
+	Unicursal
+)
+
+// Where Generate puts the exit, once the entrance is at one end of the maze's
+// longest shortest path. FarthestExit puts it at the other end. LeafExit puts
+// it in a leaf room, one with a single way in, as far from the entrance as any
+// leaf room lets it be, or at the dead end farthest from the entrance if no
+// leaf room is free of the entrance.
+const (
+	FarthestExit ExitPlacement = iota
+	LeafExit
+)
+
+const (
+	Rock = iota
+	Carved
+	Secret
+	Entrance
+	Exit
+	Door
+	// Stairs join a level made by GenerateLevels to the one below or above
+	// it, at the same cell.
+	StairsDown
+	StairsUp
+)
+
+// Passable reports whether a cell of material m can be walked through. Secret
+// passages only count as passable once allowSecrets is set.
+func Passable(m Material, allowSecrets bool) bool {
+	return m != Rock && (allowSecrets || m != Secret)
+}
+
+func passable(m Material) bool {
+	return Passable(m, false)
+}
+
+type Grid struct {
+	g        []Material
+	Size     Point
+	regions  []Region
+	regCount Region
+	rooms    []image.Rectangle
+	// roomTypes holds the type of each of rooms, or "".
+	roomTypes []string
+	mask      func(Point) bool
+}
+
+func (g *Grid) Rooms() []image.Rectangle {
+	return g.rooms
+}
+
+// RoomTypes returns the type of each of g.Rooms(), in the same order: its name
+// in RoomParams.Types, or "" for a room of no type.
+func (g *Grid) RoomTypes() []string {
+	return g.roomTypes
+}
+
+// carveRoom carves r out as a new region and remembers it as a room of the
+// given type.
+func (g *Grid) carveRoom(r image.Rectangle, kind string) {
+	if r.Empty() {
+		return
+	}
+	region := g.NewRegion()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			g.SetMaterial(Pt(x, y), Carved)
+			g.SetRegion(Pt(x, y), region)
+		}
+	}
+	g.rooms = append(g.rooms, r)
+	g.roomTypes = append(g.roomTypes, kind)
+}
+
+// Regions returns the ids, in increasing order, of the regions that some cell
+// still belongs to. Merging regions while connecting the maze retires ids, so
+// these need not be every id NewRegion has handed out.
+func (g *Grid) Regions() []Region {
+	seen := make([]bool, g.regCount+1)
+	for _, r := range g.regions {
+		seen[r] = true
+	}
+
+	regs := make([]Region, 0)
+	for r := NoRegion + 1; r <= g.regCount; r++ {
+		if seen[r] {
+			regs = append(regs, r)
+		}
+	}
+
+	return regs
+}
+
+// NewRegion hands out a fresh region id. Ids start at 1; NoRegion is never
+// handed out.
+func (g *Grid) NewRegion() Region {
+	g.regCount++
+	return g.regCount
+}
+
+func (g *Grid) Bounds() image.Rectangle {
+	return image.Rect(0, 0, g.Size.X, g.Size.Y)
+}
+
+// InBounds reports whether p is a cell of the grid.
+func (g *Grid) InBounds(p Point) bool {
+	return p.X >= 0 && p.Y >= 0 && p.X < g.Size.X && p.Y < g.Size.Y
+}
+
+// At returns the material at p. Cells outside the grid read as Rock.
+func (g *Grid) At(p Point) Material {
+	if !g.InBounds(p) {
+		return Rock
+	}
+	return g.g[p.Y*g.Size.X+p.X]
+}
+
+// RegionAt returns the region at p. Cells outside the grid read as NoRegion.
+func (g *Grid) RegionAt(p Point) Region {
+	if !g.InBounds(p) {
+		return NoRegion
+	}
+	return g.regions[p.Y*g.Size.X+p.X]
+}
+
+// SetMaterial sets the material at p, which must be inside the grid.
+func (g *Grid) SetMaterial(p Point, m Material) {
+	g.mustBeInBounds(p)
+	g.g[p.Y*g.Size.X+p.X] = m
+}
+
+// SetRegion sets the region at p, which must be inside the grid.
+func (g *Grid) SetRegion(p Point, r Region) {
+	g.mustBeInBounds(p)
+	g.regions[p.Y*g.Size.X+p.X] = r
+}
+
+func (g *Grid) mustBeInBounds(p Point) {
+	if !g.InBounds(p) {
+		panic(fmt.Sprintf("maze: cell %v outside %dx%d grid", p.Point, g.Size.X, g.Size.Y))
+	}
+}
+
+// RenderMaterials writes a PNG with every cell a block of opts.Scale pixels
+// square in its material's theme color.
+func (g *Grid) RenderMaterials(w io.Writer, opts RenderOptions) error {
+	scale, theme := opts.scale(1), opts.theme()
+	img := image.NewPaletted(scaled(g.Bounds(), scale), theme.palette())
+	fill := func(p Point) color.Color { return theme.material(g.At(p), opts.RevealSecrets) }
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			fillCell(img, Pt(x, y), scale, fill(Pt(x, y)))
+		}
+	}
+	if opts.RoundCorners {
+		roundCorners(img, g, scale, fill)
+	}
+	err := png.Encode(w, img)
+	return err
+}
+
+// RenderRegions colors every passable cell of img, opts.Scale pixels square
+// each, by region, and every wall in the theme's rock color.
+func (g *Grid) RenderRegions(img *image.Paletted, opts RenderOptions) {
+	scale, theme := opts.scale(1), opts.theme()
+	fill := func(p Point) color.Color {
+		if passable(g.At(p)) {
+			return theme.Region(g.RegionAt(p))
+		}
+		return theme.Rock
+	}
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			fillCell(img, Pt(x, y), scale, fill(Pt(x, y)))
+		}
+	}
+	if opts.RoundCorners {
+		roundCorners(img, g, scale, fill)
+	}
+}
+
+type Point struct{ image.Point }
+
+func Pt(x, y int) Point {
+	pt := image.Pt(x, y)
+	return Point{pt}
+}
+
+func (p Point) Add(o Point) Point {
+	pt := p.Point.Add(o.Point)
+	return Point{pt}
+}
+
+func (p Point) AddDir(d direction) Point {
+	return p.Add(*d.Point)
+}
+
+func (p Point) Mul(i int) Point {
+	pt := p.Point.Mul(i)
+	return Point{pt}
+}
+
+type RoomParams struct {
+	Min, Max Point
+	// Tries is how many rooms to attempt; rooms that would overlap are dropped.
+	Tries int
+	// Roominess skews room sizes: above 1 toward Max, below 1 toward Min. 0
+	// or 1 leaves every odd size between them equally likely.
+	Roominess float64
+	// Fixed rooms are carved before any random ones, which are placed around
+	// them. They must lie on the odd lattice inside the outer wall and not
+	// overlap each other.
+	Fixed []image.Rectangle
+	// Types, when set, gives every random room a type, picked for each try
+	// with odds in proportion to the type's Weight. The type's sizes then
+	// stand in for Min and Max. Fixed rooms have no type.
+	Types map[string]RoomType
+}
+
+// RoomType is a kind of room, such as a vault or a hall, with sizes and doors
+// of its own.
+type RoomType struct {
+	Weight   float64
+	Min, Max Point
+	// MaxDoors caps the doors opened into a room of this type while regions
+	// are joined, unless a region can't be reached any other way. 0 means no
+	// cap.
+	MaxDoors int
+}
+
+// pickType returns the name and sizes of a type from rp.Types picked by
+// weight, or "" and rp's own sizes if it has none. Types are tried in order
+// of name, so that the same rng picks the same type.
+func (rp RoomParams) pickType(rng *rand.Rand) (string, Point, Point) {
+	if len(rp.Types) == 0 {
+		return "", rp.Min, rp.Max
+	}
+	names := make([]string, 0, len(rp.Types))
+	total := 0.0
+	for name, t := range rp.Types {
+		names = append(names, name)
+		total += t.Weight
+	}
+	sort.Strings(names)
+	x := rng.Float64() * total
+	for _, name := range names {
+		if x -= rp.Types[name].Weight; x < 0 {
+			return name, rp.Types[name].Min, rp.Types[name].Max
+		}
+	}
+	last := names[len(names)-1]
+	return last, rp.Types[last].Min, rp.Types[last].Max
+}
+
+// maxSide returns the longest side of any room rp may pick.
+func (rp RoomParams) maxSide() int {
+	side := max(rp.Max.X, rp.Max.Y)
+	for _, t := range rp.Types {
+		side = max(side, t.Max.X, t.Max.Y)
+	}
+	return side
+}
+
+// Config describes a maze to generate.
+type Config struct {
+	Size  Point
+	Rooms RoomParams
+	Seed  int64
+	// Placement spreads the random rooms over the maze.
+	Placement Placement
+	// Algorithm carves the corridors between the rooms.
+	Algorithm Algorithm
+	// WindingPercent is the chance, in percent, that a corridor turns when it
+	// could carry straight on. Low values make long straight halls, 100
+	// leaves every direction equally likely.
+	WindingPercent int
+	// MaxCorridorRun is the longest unbranched corridor grow may carve, in
+	// steps between junctions or dead ends; a corridor that reaches it ends
+	// there. The limit is checked before a direction is picked, so it
+	// overrides WindingPercent. 0 means no limit.
+	MaxCorridorRun int
+	// HubBias makes rooms and corridors with more cells more likely to get
+	// doors while regions are joined, so that the largest rooms become hubs:
+	// each connector is picked with odds in proportion to the size, in cells,
+	// of the smaller region it touches raised to HubBias. The smaller side is
+	// used because the other is most often the corridors, which touch every
+	// connector alike. 0 picks every connector with the same odds.
+	HubBias float64
+	// ExtraConnectors is the number of redundant connectors opened once every
+	// region is connected, on top of those opened by EXTRA_CONNECTOR_CHANCE,
+	// each adding a loop. Fewer are opened if the maze has fewer to spare.
+	ExtraConnectors int
+	// SecretChance is the chance that a wall between two facing dead ends
+	// becomes a secret passage.
+	SecretChance float64
+	// RemovePillars carves out the pillars left in corridors once every region
+	// is connected; see RemovePillars. Pillars in rooms are kept.
+	RemovePillars bool
+	// Mask, when set, limits the maze to the cells it returns true for; the
+	// rest stay rock and are never carved, used for rooms or opened as
+	// connectors.
+	Mask func(Point) bool `json:"-"`
+	// Shape outlines the maze inside the grid. Cells outside it stay rock,
+	// just as if Mask returned false for them.
+	Shape Shape
+	// Polygon lists the vertices, in cell coordinates, of the outline used by
+	// the Polygon shape. A cell is inside if its center is, by the even-odd
+	// rule, so the outline may cross itself.
+	Polygon []Point
+	// Density, when set, gives the probability in [0, 1] that a corridor is
+	// started from or extended into a cell, producing denser and sparser
+	// zones.
+	Density func(Point) float64 `json:"-"`
+	// Exit picks where the exit goes.
+	Exit ExitPlacement
+	// MinCorridorCoverage, when set, is the least share, in [0, 1], of the
+	// cells outside rooms that corridors must fill. Where Density leaves too
+	// few, more corridors are grown from rock lattice cells picked at random
+	// until the share is met, and generation fails if it can't be. Fully
+	// grown corridors fill about half the cells outside rooms, and the extra
+	// corridors still extend with Density, so a low Density lowers the share
+	// that can be met.
+	MinCorridorCoverage float64
+	// SegmentRegions relabels the finished maze so that every room, junction
+	// and corridor run between them is a region of its own; see
+	// Grid.SegmentRegions. Otherwise the whole maze is one region.
+	SegmentRegions bool
+}
+
+// DefaultConfig returns the Config the maze command uses unless told
+// otherwise, for a maze of the given size.
+func DefaultConfig(size Point) Config {
+	return Config{
+		Size:           size,
+		Rooms:          RoomParams{Min: Pt(5, 5), Max: Pt(15, 15), Tries: ROOM_TRIES},
+		WindingPercent: 100,
+	}
+}
+
+// Validate reports the first setting in c that Generate can't work with.
+func (c Config) Validate() error {
+	switch {
+	case c.Size.X < 3 || c.Size.Y < 3:
+		return fmt.Errorf("maze: size %dx%d is smaller than 3x3", c.Size.X, c.Size.Y)
+	case c.Rooms.Min.X < 1 || c.Rooms.Min.Y < 1:
+		return fmt.Errorf("maze: minimum room size %dx%d is smaller than 1x1", c.Rooms.Min.X, c.Rooms.Min.Y)
+	case c.Rooms.Min.X > c.Rooms.Max.X || c.Rooms.Min.Y > c.Rooms.Max.Y:
+		return fmt.Errorf("maze: minimum room size %dx%d is larger than maximum %dx%d",
+			c.Rooms.Min.X, c.Rooms.Min.Y, c.Rooms.Max.X, c.Rooms.Max.Y)
+	case c.Rooms.Min.X|1 > c.Rooms.Max.X || c.Rooms.Min.Y|1 > c.Rooms.Max.Y:
+		return fmt.Errorf("maze: no odd room size between %dx%d and %dx%d",
+			c.Rooms.Min.X, c.Rooms.Min.Y, c.Rooms.Max.X, c.Rooms.Max.Y)
+	case c.Rooms.Tries < 0:
+		return fmt.Errorf("maze: negative room tries %d", c.Rooms.Tries)
+	case c.Rooms.Roominess < 0:
+		return fmt.Errorf("maze: negative roominess %g", c.Rooms.Roominess)
+	case c.Placement < Uniform || c.Placement > PoissonDisk:
+		return fmt.Errorf("maze: unknown room placement %d", c.Placement)
+	case c.Algorithm < GrowingTree || c.Algorithm > Unicursal:
+		return fmt.Errorf("maze: unknown algorithm %d", c.Algorithm)
+	case c.WindingPercent < 0 || c.WindingPercent > 100:
+		return fmt.Errorf("maze: winding percent %d outside 0..100", c.WindingPercent)
+	case c.MaxCorridorRun < 0:
+		return fmt.Errorf("maze: negative maximum corridor run %d", c.MaxCorridorRun)
+	case c.ExtraConnectors < 0:
+		return fmt.Errorf("maze: negative extra connectors %d", c.ExtraConnectors)
+	case c.HubBias < 0:
+		return fmt.Errorf("maze: negative hub bias %g", c.HubBias)
+	case c.SecretChance < 0 || c.SecretChance > 1:
+		return fmt.Errorf("maze: secret chance %g outside 0..1", c.SecretChance)
+	case c.Shape < Rectangle || c.Shape > Polygon:
+		return fmt.Errorf("maze: unknown shape %d", c.Shape)
+	case c.MinCorridorCoverage < 0 || c.MinCorridorCoverage > 1:
+		return fmt.Errorf("maze: minimum corridor coverage %g outside 0..1", c.MinCorridorCoverage)
+	case c.Exit < FarthestExit || c.Exit > LeafExit:
+		return fmt.Errorf("maze: unknown exit placement %d", c.Exit)
+	case c.Shape == Polygon && len(c.Polygon) < 3:
+		return fmt.Errorf("maze: polygon has %d vertices, want at least 3", len(c.Polygon))
+	}
+	total := 0.0
+	for name, t := range c.Rooms.Types {
+		switch {
+		case t.Weight < 0:
+			return fmt.Errorf("maze: room type %q has negative weight %g", name, t.Weight)
+		case t.Min.X < 1 || t.Min.Y < 1:
+			return fmt.Errorf("maze: room type %q: minimum size %dx%d is smaller than 1x1", name, t.Min.X, t.Min.Y)
+		case t.Min.X|1 > t.Max.X || t.Min.Y|1 > t.Max.Y:
+			return fmt.Errorf("maze: room type %q: no odd size between %dx%d and %dx%d", name, t.Min.X, t.Min.Y, t.Max.X, t.Max.Y)
+		case t.MaxDoors < 0:
+			return fmt.Errorf("maze: room type %q has negative maximum doors %d", name, t.MaxDoors)
+		}
+		total += t.Weight
+	}
+	if len(c.Rooms.Types) > 0 && total == 0 {
+		return fmt.Errorf("maze: room types all have weight 0")
+	}
+	return nil
+}
+
+type direction struct {
+	*Point
+}
+
+func (d direction) Reverse() direction {
+	pt := d.Point.Mul(-1)
+	return direction{&pt}
+}
+
+func D(x int, y int) direction {
+	pt := Pt(x, y)
+	return direction{&pt}
+}
+
+var Dir = struct{ Up, Right, Down, Left direction }{D(0, -1), D(1, 0), D(0, 1), D(-1, 0)}
+
+var Dirs = []direction{Dir.Up, Dir.Right, Dir.Down, Dir.Left}
+
+// Generate builds a maze as cfg describes: rooms placed per cfg.Rooms,
+// corridors grown between them and every region connected into one, with an
+// Entrance and an Exit as far apart as the maze allows. The same cfg always
+// yields the same maze. It fails, before carving anything, if any of
+// cfg.Rooms.Fixed can't be placed. If onStep is not nil it is called with the
+// grid after every room, corridor step and connector is carved, e.g. to record
+// the frames of an animation. Generation stops with ctx.Err() soon after ctx is
+// done; if that happens while corridors are growing, the error is an
+// *Interrupted wrapping it, from which ResumeState can finish the maze. With
+// the Unicursal algorithm cfg.Rooms is ignored and Solve(entrance, exit)
+// returns every carved cell in order.
+func Generate(ctx context.Context, cfg Config, onStep func(*Grid)) (grid *Grid, entrance, exit Point, err error) {
+	grid = newGrid(cfg.Size)
+	if entrance, exit, err = GenerateInto(ctx, grid, cfg, onStep); err != nil {
+		return nil, entrance, exit, err
+	}
+	return grid, entrance, exit, nil
+}
+
+// GenerateInto is Generate, but carves the maze into grid, which must be
+// cfg.Size, rather than a grid of its own. grid is Reset first, and keeps its
+// backing arrays. On error grid holds whatever was carved so far.
+func GenerateInto(ctx context.Context, grid *Grid, cfg Config, onStep func(*Grid)) (entrance, exit Point, err error) {
+	if grid.Size != cfg.Size {
+		return entrance, exit, fmt.Errorf("maze: grid is %dx%d, config wants %dx%d", grid.Size.X, grid.Size.Y, cfg.Size.X, cfg.Size.Y)
+	}
+	params := cfg.Rooms
+	src := newCountingSource(cfg.Seed, 0)
+	rng := rand.New(src)
+	grid.Reset()
+	grid.mask = cfg.mask()
+	if cfg.Algorithm == Unicursal {
+		if err := carveUnicursal(ctx, grid, rng, onStep); err != nil {
+			return entrance, exit, err
+		}
+		entrance, exit = placeEnds(grid, cfg.Exit)
+		if cfg.SegmentRegions {
+			grid.SegmentRegions()
+		}
+		return entrance, exit, nil
+	}
+	if err := checkFixedRooms(grid, params.Fixed); err != nil {
+		return entrance, exit, err
+	}
+
+	var rooms []image.Rectangle
+	var types []string
+	if cfg.Placement == PoissonDisk {
+		rooms, types, err = createRoomsPoisson(ctx, grid.Bounds(), params, params.Tries, grid.allowed, rng)
+	} else {
+		rooms, types, err = createRooms(ctx, grid.Bounds(), params, params.Tries, grid.allowed, rng)
+	}
+	if err != nil {
+		return entrance, exit, err
+	}
+
+	for i, r := range rooms {
+		grid.carveRoom(r, types[i])
+		if onStep != nil {
+			onStep(grid)
+		}
+	}
+
+	return finishMaze(ctx, grid, cfg, src, rng, &corridorState{next: Pt(1, 1)}, onStep)
+}
+
+// RegrowCorridors turns everything in g outside its rooms back to rock and
+// grows, connects and finishes the corridors again as Generate would with cfg,
+// which must be g's size, drawing from cfg.Seed. The rooms stay where they
+// are, each carved again as a region of its own, so that corridor settings
+// can be compared on one room layout. It places a new Entrance and Exit,
+// which may fall in a room. The Unicursal algorithm, which places no rooms,
+// is rejected.
+func RegrowCorridors(ctx context.Context, g *Grid, cfg Config, onStep func(*Grid)) (entrance, exit Point, err error) {
+	if g.Size != cfg.Size {
+		return entrance, exit, fmt.Errorf("maze: grid is %dx%d, config wants %dx%d", g.Size.X, g.Size.Y, cfg.Size.X, cfg.Size.Y)
+	}
+	if cfg.Algorithm == Unicursal {
+		return entrance, exit, fmt.Errorf("maze: can't regrow the corridors of a unicursal maze")
+	}
+	src := newCountingSource(cfg.Seed, 0)
+	rng := rand.New(src)
+
+	rooms, types := g.rooms, g.roomTypes
+	g.Reset()
+	g.mask = cfg.mask()
+	for i, r := range rooms {
+		g.carveRoom(r, types[i])
+	}
+	return finishMaze(ctx, g, cfg, src, rng, &corridorState{next: Pt(1, 1)}, onStep)
+}
+
+// finishMaze grows the corridors of a grid whose rooms are carved, from st on,
+// then connects it and places its secrets and ends. rng must draw from src. If
+// ctx is done while corridors are growing, the error is an *Interrupted that
+// ResumeState can carry on from.
+func finishMaze(ctx context.Context, grid *Grid, cfg Config, src *countingSource, rng *rand.Rand, st *corridorState, onStep func(*Grid)) (entrance, exit Point, err error) {
+	if err := growCorridors(ctx, grid, cfg, rng, onStep, st); err != nil {
+		if err == ctx.Err() {
+			err = &Interrupted{err, &GenerationState{grid, cfg.Seed, src.draws, *st}}
+		}
+		return entrance, exit, err
+	}
+	if cfg.MinCorridorCoverage > 0 {
+		if err := coverCorridors(ctx, grid, cfg, rng, onStep); err != nil {
+			return entrance, exit, err
+		}
+	}
+	if err := connectRegions(ctx, grid, cfg, rng, onStep); err != nil {
+		return entrance, exit, err
+	}
+
+	if cfg.RemovePillars {
+		RemovePillars(grid, false)
+	}
+	if cfg.SecretChance > 0 {
+		placeSecrets(grid, cfg.SecretChance, rng)
+	}
+
+	entrance, exit = placeEnds(grid, cfg.Exit)
+	if cfg.SegmentRegions {
+		grid.SegmentRegions()
+	}
+	return entrance, exit, nil
+}
+
+// checkFixedRooms returns an error listing every room that can't be carved
+// into g as given, and why.
+func checkFixedRooms(g *Grid, rooms []image.Rectangle) error {
+	rejects := make([]string, 0)
+	for i, r := range rooms {
+		reason := ""
+		switch {
+		case r.Empty():
+			reason = "empty"
+		case !r.In(g.Bounds().Inset(1)):
+			reason = "not inside the outer wall"
+		case r.Min.X%2 == 0 || r.Min.Y%2 == 0 || r.Dx()%2 == 0 || r.Dy()%2 == 0:
+			reason = "not on the odd lattice"
+		case !allowedRect(r, g.allowed):
+			reason = "outside the mask"
+		}
+		for _, o := range rooms[:i] {
+			if reason == "" && r.Overlaps(o) {
+				reason = fmt.Sprintf("overlaps %v", o)
+			}
+		}
+		if reason != "" {
+			rejects = append(rejects, fmt.Sprintf("%v %s", r, reason))
+		}
+	}
+
+	if len(rejects) > 0 {
+		return fmt.Errorf("maze: can't place rooms: %s", strings.Join(rejects, "; "))
+	}
+	return nil
+}
+
+func newGrid(size Point) *Grid {
+	return &Grid{
+		make([]Material, size.X*size.Y),
+		size,
+		make([]Region, size.X*size.Y),
+		0,
+		nil,
+		nil,
+		nil,
+	}
+}
+
+// mask returns the cells c.Shape and c.Mask together let generation carve, or
+// nil if that is every cell.
+func (c Config) mask() func(Point) bool {
+	mask := c.Mask
+	var inside func(Point) bool
+	switch c.Shape {
+	case Circle:
+		// Measure from cell centers, so the circle is centered on odd and
+		// even sizes alike.
+		cx, cy := float64(c.Size.X-1)/2, float64(c.Size.Y-1)/2
+		r := float64(min(c.Size.X, c.Size.Y)-1) / 2
+		inside = func(p Point) bool {
+			dx, dy := float64(p.X)-cx, float64(p.Y)-cy
+			return dx*dx+dy*dy <= r*r
+		}
+	case Polygon:
+		poly := c.Polygon
+		inside = func(p Point) bool { return inPolygon(p, poly) }
+	default:
+		return mask
+	}
+	return func(p Point) bool {
+		return inside(p) && (mask == nil || mask(p))
+	}
+}
+
+// inPolygon reports whether p lies inside poly by the even-odd rule: whether a
+// ray from p to the right crosses its edges an odd number of times.
+func inPolygon(p Point, poly []Point) bool {
+	in := false
+	for i, a := range poly {
+		b := poly[(i+1)%len(poly)]
+		if (a.Y > p.Y) != (b.Y > p.Y) {
+			x := float64(a.X) + float64(p.Y-a.Y)*float64(b.X-a.X)/float64(b.Y-a.Y)
+			if float64(p.X) < x {
+				in = !in
+			}
+		}
+	}
+	return in
+}
+
+// Reset turns every cell of g back to rock in no region and forgets its
+// regions, rooms and mask, leaving it as a new grid of its size would be.
+func (g *Grid) Reset() {
+	clear(g.g)
+	clear(g.regions)
+	g.regCount = 0
+	g.rooms = nil
+	g.roomTypes = nil
+	g.mask = nil
+}
+
+// allowed reports whether generation may carve p.
+func (g *Grid) allowed(p Point) bool {
+	return g.mask == nil || g.mask(p)
+}
+
+// createRooms makes tries attempts at placing a random room inside clip,
+// keeping those that don't overlap a room already placed. The rooms returned
+// start with rp.Fixed, which every random room avoids. Rooms start on the
+// odd lattice relative to clip and keep clear of its outermost cells, which
+// stay wall whatever clip's size. Rooms with any cell not allowed are dropped.
+// The type of each room, as picked from rp.Types, is returned alongside it.
+func createRooms(ctx context.Context, clip image.Rectangle, rp RoomParams, tries int, allowed func(Point) bool, rng *rand.Rand) ([]image.Rectangle, []string, error) {
+	rooms := append(make([]image.Rectangle, 0), rp.Fixed...)
+	types := make([]string, len(rooms))
+
+TryingRooms:
+	for i := 0; i < tries; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		x := clip.Min.X + rng.Intn(max(1, clip.Dx()/2))*2 + 1
+		y := clip.Min.Y + rng.Intn(max(1, clip.Dy()/2))*2 + 1
+		kind, lo, hi := rp.pickType(rng)
+		width := roomSide(lo.X, hi.X, rp.Roominess, rng)
+		height := roomSide(lo.Y, hi.Y, rp.Roominess, rng)
+		room := image.Rect(x, y, x+width, y+height)
+
+		if !room.In(clip.Inset(1)) || !allowedRect(room, allowed) {
+			continue TryingRooms
+		}
+
+		for _, old := range rooms {
+			if room.Overlaps(old) {
+				continue TryingRooms
+			}
+		}
+
+		rooms = append(rooms, room)
+		types = append(types, kind)
+	}
+
+	return rooms, types, nil
+}
+
+// roomSide picks an odd room side between lo and hi, so that a room starting
+// on the odd lattice also ends on it. Sides are equally likely unless
+// roominess is set: above 1 it favors the larger sides, below 1 the smaller.
+// If no odd side fits, the smallest odd side not below lo is used.
+func roomSide(lo, hi int, roominess float64, rng *rand.Rand) int {
+	lo |= 1
+	if hi%2 == 0 {
+		hi--
+	}
+	steps := max(0, (hi-lo)/2)
+
+	if roominess <= 0 || roominess == 1 {
+		return lo + 2*rng.Intn(steps+1)
+	}
+	k := int(math.Pow(rng.Float64(), 1/roominess) * float64(steps+1))
+	return lo + 2*min(k, steps)
+}
+
+func allowedRect(r image.Rectangle, allowed func(Point) bool) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if !allowed(Pt(x, y)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// createRoomsPoisson places rooms around Poisson-disk distributed centers, so
+// that no two centers are closer than the largest room dimension. The spacing
+// is widened until clip holds about tries centers, since trying only some of
+// a denser set would pick them as unevenly as uniform placement. Centers are
+// snapped so rooms stay on the odd lattice. At most tries rooms are attempted,
+// and rooms with any cell not allowed are dropped. As with createRooms, the
+// rooms returned start with rp.Fixed, and their types come alongside them.
+func createRoomsPoisson(ctx context.Context, clip image.Rectangle, rp RoomParams, tries int, allowed func(Point) bool, rng *rand.Rand) ([]image.Rectangle, []string, error) {
+	rooms := append(make([]image.Rectangle, 0), rp.Fixed...)
+	types := make([]string, len(rooms))
+	spacing := 0.8 * math.Sqrt(float64(clip.Dx()*clip.Dy())/float64(max(tries, 1)))
+	centers := poissonDisk(clip, math.Max(float64(rp.maxSide()), spacing), 30, rng)
+	rng.Shuffle(len(centers), func(i, j int) {
+		centers[i], centers[j] = centers[j], centers[i]
+	})
+
+TryingRooms:
+	for i := 0; i < tries && i < len(centers); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		kind, lo, hi := rp.pickType(rng)
+		width := roomSide(lo.X, hi.X, rp.Roominess, rng)
+		height := roomSide(lo.Y, hi.Y, rp.Roominess, rng)
+		x := (centers[i].X-width/2)/2*2 + 1
+		y := (centers[i].Y-height/2)/2*2 + 1
+		room := image.Rect(x, y, x+width, y+height)
+
+		if !room.In(clip.Inset(1)) || !allowedRect(room, allowed) {
+			continue TryingRooms
+		}
+
+		for _, old := range rooms {
+			if room.Overlaps(old) {
+				continue TryingRooms
+			}
+		}
+
+		rooms = append(rooms, room)
+		types = append(types, kind)
+	}
+
+	return rooms, types, nil
+}
+
+// poissonDisk samples points in clip no closer than r to each other using
+// Bridson's algorithm, trying k candidates around each active sample.
+func poissonDisk(clip image.Rectangle, r float64, k int, rng *rand.Rand) []image.Point {
+	start := image.Pt(clip.Min.X+rng.Intn(clip.Dx()), clip.Min.Y+rng.Intn(clip.Dy()))
+	samples := []image.Point{start}
+	active := []image.Point{start}
+
+	for len(active) > 0 {
+		i := rng.Intn(len(active))
+		from := active[i]
+		found := false
+
+	Candidates:
+		for j := 0; j < k; j++ {
+			angle := rng.Float64() * 2 * math.Pi
+			dist := r * (1 + rng.Float64())
+			p := image.Pt(
+				from.X+int(math.Round(dist*math.Cos(angle))),
+				from.Y+int(math.Round(dist*math.Sin(angle))),
+			)
+			if !p.In(clip) {
+				continue
+			}
+			for _, s := range samples {
+				dx, dy := float64(p.X-s.X), float64(p.Y-s.Y)
+				if dx*dx+dy*dy < r*r {
+					continue Candidates
+				}
+			}
+			samples = append(samples, p)
+			active = append(active, p)
+			found = true
+			break
+		}
+
+		if !found {
+			active[i] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
+	}
+
+	return samples
+}
+
+func growMaze(ctx context.Context, grid *Grid, cfg Config, rng *rand.Rand, onStep func(*Grid)) error {
+	return growCorridors(ctx, grid, cfg, rng, onStep, &corridorState{next: Pt(1, 1)})
+}
+
+// corridorState is how far growCorridors has got: the lattice cell it visits
+// next and the corridor it is growing, if any.
+type corridorState struct {
+	next    Point
+	growing *growth
+}
+
+// growCorridors grows a corridor from every rock lattice cell left, starting
+// at st.next, and keeps st up to date so that it can pick up where it stopped
+// if ctx is done.
+func growCorridors(ctx context.Context, grid *Grid, cfg Config, rng *rand.Rand, onStep func(*Grid), st *corridorState) error {
+	bounds := grid.Bounds()
+
+	for st.next.Y < bounds.Max.Y-1 {
+		p := st.next
+		if st.growing != nil {
+			if err := st.growing.run(ctx, grid, cfg, rng, onStep); err != nil {
+				return err
+			}
+			st.growing = nil
+		} else if p.X >= bounds.Max.X-1 {
+			st.next = Pt(bounds.Min.X+1, p.Y+2)
+			continue
+		} else if grid.At(p) == Rock && grid.allowed(p) &&
+			(cfg.Density == nil || rng.Float64() < cfg.Density(p)) {
+			st.growing = startGrowth(grid, p, grid.NewRegion(), onStep)
+			continue
+		}
+		st.next = p.Add(Pt(2, 0))
+	}
+	return nil
+}
+
+// coverCorridors grows more corridors, each from a rock lattice cell picked at
+// random, until they fill cfg.MinCorridorCoverage of the cells outside rooms
+// that grid allows. It fails if every lattice cell is carved first.
+func coverCorridors(ctx context.Context, grid *Grid, cfg Config, rng *rand.Rand, onStep func(*Grid)) error {
+	index := roomIndex(grid)
+	area, carved := 0, 0
+	for y := 0; y < grid.Size.Y; y++ {
+		for x := 0; x < grid.Size.X; x++ {
+			if p := Pt(x, y); index[y*grid.Size.X+x] < 0 && grid.allowed(p) {
+				area++
+				if passable(grid.At(p)) {
+					carved++
+				}
+			}
+		}
+	}
+	want := int(math.Ceil(cfg.MinCorridorCoverage * float64(area)))
+
+	bounds := grid.Bounds()
+	starts := make([]Point, 0)
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y += 2 {
+		for x := bounds.Min.X + 1; x < bounds.Max.X-1; x += 2 {
+			if p := Pt(x, y); grid.At(p) == Rock && grid.allowed(p) {
+				starts = append(starts, p)
+			}
+		}
+	}
+	rng.Shuffle(len(starts), func(i, j int) { starts[i], starts[j] = starts[j], starts[i] })
+
+	for _, p := range starts {
+		if carved >= want {
+			return nil
+		}
+		if grid.At(p) != Rock {
+			continue
+		}
+		gr := startGrowth(grid, p, grid.NewRegion(), onStep)
+		if err := gr.run(ctx, grid, cfg, rng, onStep); err != nil {
+			return err
+		}
+		// Past its first cell, the growth carves two cells a step and keeps
+		// the heading of the second.
+		carved += 1 + 2*len(gr.heading)
+	}
+	if carved < want {
+		return fmt.Errorf("maze: corridors cover %d of %d cells outside rooms, short of the minimum %g",
+			carved, area, cfg.MinCorridorCoverage)
+	}
+	return nil
+}
+
+// grow carves corridors from `from` into region with cfg.Algorithm, turning
+// as cfg.WindingPercent says, ending runs at cfg.MaxCorridorRun and carving
+// into each cell with cfg.Density.
+func grow(ctx context.Context, grid *Grid, from Point, region Region, cfg Config, rng *rand.Rand, onStep func(*Grid)) error {
+	return startGrowth(grid, from, region, onStep).run(ctx, grid, cfg, rng, onStep)
+}
+
+// growth is a corridor being grown: its frontier cells and the direction each
+// was carved from.
+type growth struct {
+	region  Region
+	cells   []Point
+	heading map[Point]direction
+}
+
+// startGrowth carves from into region and returns the growth of a corridor
+// from it.
+func startGrowth(grid *Grid, from Point, region Region, onStep func(*Grid)) *growth {
+	grid.SetMaterial(from, Carved)
+	grid.SetRegion(from, region)
+	if onStep != nil {
+		onStep(grid)
+	}
+	return &growth{region, []Point{from}, make(map[Point]direction)}
+}
+
+// run grows the corridor until its frontier is empty. If ctx is done it stops
+// before the next step, leaving gr ready to run again.
+func (gr *growth) run(ctx context.Context, grid *Grid, cfg Config, rng *rand.Rand, onStep func(*Grid)) error {
+	for len(gr.cells) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		at := len(gr.cells) - 1
+		if cfg.Algorithm == GrowingTree {
+			at = rng.Intn(len(gr.cells))
+		}
+		cell := gr.cells[at]
+
+		unmade := make([]direction, 0)
+
+		for _, d := range Dirs {
+			if !canCarve(grid, cell, d) {
+				continue
+			}
+			if cfg.Density == nil || rng.Float64() < cfg.Density(cell.AddDir(d).AddDir(d)) {
+				unmade = append(unmade, d)
+			}
+		}
+
+		if cfg.MaxCorridorRun > 0 && corridorRun(grid, cell, cfg.MaxCorridorRun)+2 > cfg.MaxCorridorRun {
+			unmade = unmade[:0]
+		}
+
+		if len(unmade) > 0 {
+			dir := unmade[rng.Intn(len(unmade))]
+			if last, ok := gr.heading[cell]; ok && cfg.WindingPercent < 100 && rng.Intn(100) >= cfg.WindingPercent {
+				for _, d := range unmade {
+					if *d.Point == *last.Point {
+						dir = d
+					}
+				}
+			}
+			grid.SetMaterial(cell.AddDir(dir), Carved)
+			grid.SetRegion(cell.AddDir(dir), gr.region)
+			grid.SetMaterial(cell.AddDir(dir).AddDir(dir), Carved)
+			grid.SetRegion(cell.AddDir(dir).AddDir(dir), gr.region)
+			gr.cells = append(gr.cells, cell.AddDir(dir).AddDir(dir))
+			gr.heading[cell.AddDir(dir).AddDir(dir)] = dir
+			if onStep != nil {
+				onStep(grid)
+			}
+		} else {
+			// Order doesn't matter to GrowingTree, so swap the dead cell
+			// with the last one to remove it in constant time.
+			gr.cells[at] = gr.cells[len(gr.cells)-1]
+			gr.cells = gr.cells[:len(gr.cells)-1]
+		}
+	}
+	return nil
+}
+
+// corridorRun measures the unbranched corridor that carving onward from the
+// dead end p would extend: the number of steps from p back to the nearest
+// junction or dead end, up to limit. It is 0 unless p is a dead end, since
+// carving from anywhere else starts a new branch.
+func corridorRun(g *Grid, p Point, limit int) int {
+	ns := g.passages(p)
+	if len(ns) != 1 {
+		return 0
+	}
+
+	prev, cur, n := p, ns[0], 1
+	for n <= limit {
+		ns = g.passages(cur)
+		if len(ns) != 2 {
+			break
+		}
+		if ns[0] == prev {
+			prev, cur = cur, ns[1]
+		} else {
+			prev, cur = cur, ns[0]
+		}
+		n++
+	}
+
+	return n
+}
+
+func canCarve(g *Grid, from Point, dir direction) bool {
+	beyond := from.AddDir(dir).AddDir(dir).AddDir(dir)
+	next := from.AddDir(dir).AddDir(dir)
+
+	return beyond.In(g.Bounds()) && g.At(next) == Rock && g.allowed(from.AddDir(dir)) && g.allowed(next)
+}
+
+// connectRegions joins every region into one connected maze through the
+// connectors between them, turning each one opened into a Door; see
+// joinRegions. Connectors left joining the connected set to itself are
+// discarded, except that each is opened with EXTRA_CONNECTOR_CHANCE to make
+// loops, and at the end cfg.ExtraConnectors of the discarded ones are picked
+// at random and opened. With cfg.HubBias, as Config.HubBias says, connectors
+// are weighted in every one of these choices, the loop chance scaled by
+// weight over the average weight. No connector is opened into a room that
+// already has the MaxDoors of its type; if that leaves regions unreached,
+// they are joined in a second pass that ignores the caps.
+func connectRegions(ctx context.Context, g *Grid, cfg Config, rng *rand.Rand, onStep func(*Grid)) error {
+	conns := findConnectors(g)
+	if len(conns) == 0 {
+		return nil
+	}
+
+	// odds scales the chance of opening a connector as a loop, so that with
+	// hubBias the connectors of large regions get more than their share.
+	var weight func(connector) float64
+	odds := func(connector) float64 { return 1 }
+	if hubBias := cfg.HubBias; hubBias > 0 {
+		size := make([]int, g.regCount+1)
+		for _, r := range g.regions {
+			size[r]++
+		}
+		weight = func(c connector) float64 {
+			return math.Pow(float64(min(size[c.a.region], size[c.b.region])), hubBias)
+		}
+		mean := 0.0
+		for _, c := range conns {
+			mean += weight(c) / float64(len(conns))
+		}
+		odds = func(c connector) float64 { return weight(c) / mean }
+	}
+
+	// doors counts down the doors each capped room, by index into g.rooms,
+	// may still get.
+	doors := make(map[int]int)
+	for i, kind := range g.roomTypes {
+		if t, ok := cfg.Rooms.Types[kind]; ok && t.MaxDoors > 0 {
+			doors[i] = t.MaxDoors
+		}
+	}
+	var index []int
+	if len(doors) > 0 {
+		index = roomIndex(g)
+	}
+	// rooms returns the capped rooms on either side of c.
+	rooms := func(c connector) []int {
+		if index == nil {
+			return nil
+		}
+		capped := make([]int, 0, 2)
+		for _, d := range []direction{c.a.dir, c.b.dir} {
+			p := c.loc.AddDir(d)
+			if i := index[p.Y*g.Size.X+p.X]; i >= 0 {
+				if _, ok := doors[i]; ok {
+					capped = append(capped, i)
+				}
+			}
+		}
+		return capped
+	}
+	capped := func(c connector) bool {
+		for _, r := range rooms(c) {
+			if doors[r] <= 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	open := func(c connector, region Region) {
+		g.SetMaterial(c.loc, Door)
+		g.SetRegion(c.loc, region)
+		for _, r := range rooms(c) {
+			doors[r]--
+		}
+		if onStep != nil {
+			onStep(g)
+		}
+	}
+	discarded := make([]connector, 0)
+
+	main, sets, err := joinRegions(ctx, conns, g.regCount, rng,
+		func(c connector) (Region, Region) { return c.a.region, c.b.region },
+		func(c connector) Point { return c.loc },
+		weight,
+		func(c connector, main Region) bool {
+			if capped(c) {
+				return false
+			}
+			open(c, main)
+			return true
+		},
+		func(c connector, main Region) {
+			if !capped(c) && rng.Float64() < EXTRA_CONNECTOR_CHANCE*odds(c) && !nextToOpening(g, c) {
+				open(c, main)
+			} else {
+				discarded = append(discarded, c)
+			}
+		})
+	if err != nil {
+		return err
+	}
+	for i, r := range g.regions {
+		g.regions[i] = sets.find(r)
+	}
+
+	if len(doors) > 0 {
+		if rest := findConnectors(g); len(rest) > 0 {
+			main, sets, err = joinRegions(ctx, rest, g.regCount, rng,
+				func(c connector) (Region, Region) { return c.a.region, c.b.region },
+				func(c connector) Point { return c.loc },
+				nil,
+				func(c connector, main Region) bool {
+					open(c, main)
+					return true
+				},
+				func(connector, Region) {})
+			if err != nil {
+				return err
+			}
+			for i, r := range g.regions {
+				g.regions[i] = sets.find(r)
+			}
+		}
+	}
+
+	if extra := cfg.ExtraConnectors; extra > 0 {
+		if weight == nil {
+			rng.Shuffle(len(discarded), func(i, j int) { discarded[i], discarded[j] = discarded[j], discarded[i] })
+		} else {
+			// Sorting by u^(1/weight) for uniform u orders the connectors
+			// as drawing them one by one in proportion to weight would.
+			keys := make(map[Point]float64, len(discarded))
+			for _, c := range discarded {
+				keys[c.loc] = math.Pow(rng.Float64(), 1/weight(c))
+			}
+			sort.SliceStable(discarded, func(i, j int) bool { return keys[discarded[i].loc] > keys[discarded[j].loc] })
+		}
+		for _, o := range discarded {
+			if extra == 0 {
+				break
+			}
+			if g.At(o.loc) == Rock && !capped(o) && !nextToOpening(g, o) {
+				open(o, main)
+				extra--
+			}
+		}
+	}
+	return nil
+}
+
+// joinRegions merges regions 1..count into one through conns, each joining
+// the two regions ends returns for it at the cell at returns. Starting from a
+// random connector's region, it repeatedly opens a random bridge, a connector
+// with one side in the connected set, and merges in the region on its other
+// side. Bridges are picked with the same odds, or in proportion to weight if
+// it is not nil. open may refuse a bridge, which is then dropped. Merges are
+// tracked in a union-find over region ids, and the bridges are kept up to date
+// as regions merge, from each region's own connectors, rather than found again
+// among all of them. Connectors found joining the connected set to itself are
+// passed to loop, except those sharing a cell with a connector already opened.
+// It returns the connected set's region, or NoRegion if there are no
+// connectors, and the sets, with which every region can be relabelled once at
+// the end.
+func joinRegions[C any, K comparable](ctx context.Context, conns []C, count Region, rng *rand.Rand,
+	ends func(C) (Region, Region), at func(C) K, weight func(C) float64,
+	open func(C, Region) bool, loop func(C, Region)) (Region, regionSets, error) {
+	sets := newRegionSets(count)
+	sides := func(c C) (Region, Region) {
+		a, b := ends(c)
+		return sets.find(a), sets.find(b)
+	}
+
+	// byRegion lists the connectors touching each region, and byCell those
+	// at each cell, in order. bridges holds the connectors with one side in
+	// the connected set, and pos[i] is connector i's position in it, or -1.
+	byRegion := make(map[Region][]int)
+	byCell := make(map[K][]int)
+	for i, c := range conns {
+		a, b := ends(c)
+		byRegion[a] = append(byRegion[a], i)
+		byRegion[b] = append(byRegion[b], i)
+		byCell[at(c)] = append(byCell[at(c)], i)
+	}
+	bridges := make([]int, 0)
+	pos := make([]int, len(conns))
+	for i := range pos {
+		pos[i] = -1
+	}
+	addBridge := func(i int) {
+		if pos[i] < 0 {
+			pos[i] = len(bridges)
+			bridges = append(bridges, i)
+		}
+	}
+	dropBridge := func(i int) {
+		if pos[i] >= 0 {
+			last := bridges[len(bridges)-1]
+			bridges[pos[i]], pos[last] = last, pos[i]
+			bridges = bridges[:len(bridges)-1]
+			pos[i] = -1
+		}
+	}
+	// done marks the connectors at a cell already opened as a bridge, which
+	// are dropped like those found joining the connected set to itself.
+	done := make([]bool, len(conns))
+	var weights []float64
+	if weight != nil {
+		weights = make([]float64, len(conns))
+		for i, c := range conns {
+			weights[i] = weight(c)
+		}
+	}
+	if len(conns) == 0 {
+		return NoRegion, sets, nil
+	}
+
+	main, _ := sides(conns[rng.Intn(len(conns))])
+	for _, i := range byRegion[main] {
+		addBridge(i)
+	}
+
+	for len(bridges) > 0 {
+		if err := ctx.Err(); err != nil {
+			return main, sets, err
+		}
+
+		var ci int
+		if weights == nil {
+			ci = bridges[rng.Intn(len(bridges))]
+		} else {
+			ci = pickWeighted(bridges, weights, rng)
+		}
+		c := conns[ci]
+		merged, _ := sides(c)
+		if merged == main {
+			_, merged = sides(c)
+		}
+		if !open(c, main) {
+			done[ci] = true
+			dropBridge(ci)
+			continue
+		}
+		sets.union(main, merged)
+		for _, j := range byCell[at(c)] {
+			done[j] = true
+			dropBridge(j)
+		}
+
+		for _, o := range byRegion[merged] {
+			if done[o] {
+				continue
+			}
+			if ra, rb := sides(conns[o]); ra != main || rb != main {
+				addBridge(o)
+				continue
+			}
+			dropBridge(o)
+			loop(conns[o], main)
+		}
+	}
+
+	return main, sets, nil
+}
+
+// pickWeighted picks one of items at random, each with odds in proportion to
+// weights[item].
+func pickWeighted(items []int, weights []float64, rng *rand.Rand) int {
+	total := 0.0
+	for _, i := range items {
+		total += weights[i]
+	}
+	x := rng.Float64() * total
+	for _, i := range items {
+		if x -= weights[i]; x < 0 {
+			return i
+		}
+	}
+	return items[len(items)-1]
+}
+
+// placeEnds marks one end of the maze's longest shortest path, found by
+// double breadth-first search from the first passable cell, as its Entrance,
+// and places its Exit as placement says. Both are left as they are on a grid
+// with nothing carved.
+func placeEnds(g *Grid, placement ExitPlacement) (entrance, exit Point) {
+	start, ok := g.firstPassable()
+	if !ok {
+		return start, start
+	}
+	entrance, _ = g.farthest(start)
+	exit, _ = g.farthest(entrance)
+	if placement == LeafExit {
+		exit = leafExit(g, entrance, exit)
+	}
+
+	g.SetMaterial(entrance, Entrance)
+	g.SetMaterial(exit, Exit)
+	return entrance, exit
+}
+
+// leafExit returns the cell farthest from entrance in the leaf rooms without
+// it, or else the dead end farthest from it, or else fallback. Ties go to the
+// first cell in row-major order.
+func leafExit(g *Grid, entrance, fallback Point) Point {
+	dist := g.DistanceFrom(entrance, Moves{})
+	pick := func(keep func(Point) bool) (Point, bool) {
+		best, far := fallback, -1
+		for y := 0; y < g.Size.Y; y++ {
+			for x := 0; x < g.Size.X; x++ {
+				p := Pt(x, y)
+				if d, ok := dist[p]; ok && d > far && p != entrance && keep(p) {
+					best, far = p, d
+				}
+			}
+		}
+		return best, far >= 0
+	}
+
+	leaves := make([]image.Rectangle, 0)
+	for _, r := range g.rooms {
+		if !entrance.In(r) && len(roomOpenings(g, r)) == 1 {
+			leaves = append(leaves, r)
+		}
+	}
+	if p, ok := pick(func(p Point) bool {
+		for _, r := range leaves {
+			if p.In(r) {
+				return true
+			}
+		}
+		return false
+	}); ok {
+		return p
+	}
+	if p, ok := pick(func(p Point) bool { return isDeadEnd(g, p) }); ok {
+		return p
+	}
+	return fallback
+}
+
+// roomOpenings returns the passable cells orthogonally next to room r, in
+// row-major order.
+func roomOpenings(g *Grid, r image.Rectangle) []Point {
+	open := make([]Point, 0)
+	for y := r.Min.Y - 1; y <= r.Max.Y; y++ {
+		for x := r.Min.X - 1; x <= r.Max.X; x++ {
+			p := Pt(x, y)
+			side := (x >= r.Min.X && x < r.Max.X) != (y >= r.Min.Y && y < r.Max.Y)
+			if side && passable(g.At(p)) {
+				open = append(open, p)
+			}
+		}
+	}
+	return open
+}
+
+// nextToOpening reports whether c's connector cell already has an opening on
+// either side across its axis, which would make opening it a double door.
+func nextToOpening(g *Grid, c connector) bool {
+	for _, d := range Dirs {
+		across := *d.Point != *c.a.dir.Point && *d.Point != *c.b.dir.Point
+		if across && passable(g.At(c.loc.AddDir(d))) {
+			return true
+		}
+	}
+	return false
+}
+
+type conn struct {
+	dir    direction
+	region Region
+}
+
+type connector struct {
+	a, b conn
+	loc  Point
+}
+
+func findConnectors(g *Grid) []connector {
+	bounds := g.Bounds()
+	conns := make([]connector, 0)
+
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y += 1 {
+		for x := bounds.Min.X + 1; x < bounds.Max.X-1; x += 1 {
+			here := Pt(x, y)
+			mat := g.At(here)
+			if mat != Rock || !g.allowed(here) {
+				continue
+			}
+			for _, dir := range []direction{Dir.Up, Dir.Right} {
+				theOtherWay := dir.Reverse()
+				a := here.AddDir(dir)
+				b := here.AddDir(theOtherWay)
+				ra := g.RegionAt(a)
+				rb := g.RegionAt(b)
+
+				if !passable(g.At(a)) || !passable(g.At(b)) {
+					continue
+				}
+
+				if ra != rb {
+					conns = append(conns, connector{
+						a:   conn{dir: dir, region: ra},
+						b:   conn{dir: theOtherWay, region: rb},
+						loc: here,
+					})
+				}
+			}
+		}
+	}
+
+	return conns
+}
+
+// RenderAnnotated draws the grid's regions opts.Scale pixels square per cell,
+// marking the connectors still left between regions and the cells of path, if
+// any, with a ruler along the top and left edges if opts.RulerEvery is set.
+func (g *Grid) RenderAnnotated(path []Point, opts RenderOptions) *image.Paletted {
+	scale, theme := opts.scale(1), opts.theme()
+	img := image.NewPaletted(scaled(g.Bounds(), scale), theme.palette())
+	g.RenderRegions(img, opts)
+	renderConnectors(img, findConnectors(g), scale, theme)
+	renderPath(img, path, scale, theme)
+	renderEnds(img, g, scale, theme)
+	if opts.RevealSecrets {
+		renderSecrets(img, g, scale, theme)
+	}
+	if opts.RulerEvery > 0 {
+		img = withRuler(img, opts.RulerEvery, scale)
+	}
+
+	return img
+}
+
+// scaled returns r with every cell grown to a scale×scale block.
+func scaled(r image.Rectangle, scale int) image.Rectangle {
+	return image.Rectangle{r.Min.Mul(scale), r.Max.Mul(scale)}
+}
+
+// fillCell paints the scale×scale block of pixels standing for cell p.
+func fillCell(img draw.Image, p Point, scale int, c color.Color) {
+	for y := 0; y < scale; y++ {
+		for x := 0; x < scale; x++ {
+			img.Set(p.X*scale+x, p.Y*scale+y, c)
+		}
+	}
+}
+
+func renderConnectors(img *image.Paletted, conns []connector, scale int, theme Theme) {
+	for _, c := range conns {
+		fillCell(img, c.loc, scale, theme.Connector)
+	}
+}
+
+func renderPath(img *image.Paletted, path []Point, scale int, theme Theme) {
+	for _, p := range path {
+		fillCell(img, p, scale, theme.Path)
+	}
+}
+
+func renderEnds(img *image.Paletted, g *Grid, scale int, theme Theme) {
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if m := g.At(Pt(x, y)); m == Entrance || m == Exit || m == StairsDown || m == StairsUp {
+				fillCell(img, Pt(x, y), scale, theme.material(m, false))
+			}
+		}
+	}
+}
+
+func renderSecrets(img *image.Paletted, g *Grid, scale int, theme Theme) {
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if g.At(Pt(x, y)) == Secret {
+				fillCell(img, Pt(x, y), scale, theme.Secret)
+			}
+		}
+	}
+}
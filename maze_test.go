@@ -0,0 +1,795 @@
+package maze
+
+import (
+	"context"
+	"errors"
+	"image"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testConfig returns a Config with a typical spread of rooms.
+func testConfig(size Point, seed int64) Config {
+	cfg := DefaultConfig(size)
+	cfg.Rooms = RoomParams{Min: Pt(3, 3), Max: Pt(9, 9), Tries: 20}
+	cfg.Seed = seed
+	return cfg
+}
+
+// parseGrid builds a grid from rows of text: '#' is Rock, ' ' Carved, '+'
+// Door, '$' Secret, '<' Entrance and '>' Exit. Every passable cell is put in
+// region 1.
+func parseGrid(rows ...string) *Grid {
+	g := newGrid(Pt(len(rows[0]), len(rows)))
+	region := g.NewRegion()
+	mats := map[rune]Material{'#': Rock, ' ': Carved, '+': Door, '$': Secret, '<': Entrance, '>': Exit}
+	for y, row := range rows {
+		for x, c := range row {
+			g.SetMaterial(Pt(x, y), mats[c])
+			if mats[c] != Rock {
+				g.SetRegion(Pt(x, y), region)
+			}
+		}
+	}
+	return g
+}
+
+func mustGenerate(t testing.TB, cfg Config) (*Grid, Point, Point) {
+	t.Helper()
+	g, entrance, exit, err := Generate(context.Background(), cfg, nil)
+	if err != nil {
+		t.Fatalf("Generate(%+v): %s", cfg, err)
+	}
+	return g, entrance, exit
+}
+
+func TestGenerateAlgorithms(t *testing.T) {
+	for _, algo := range []Algorithm{GrowingTree, Backtracker} {
+		cfg := testConfig(Pt(41, 31), 1)
+		cfg.Algorithm = algo
+		g, _, _ := mustGenerate(t, cfg)
+
+		if err := g.Check(); err != nil {
+			t.Errorf("algorithm %d: %s", algo, err)
+		}
+		if !g.IsFullyConnected(Moves{}) {
+			t.Errorf("algorithm %d: maze is not fully connected", algo)
+		}
+		for y := 1; y < g.Size.Y-1; y += 2 {
+			for x := 1; x < g.Size.X-1; x += 2 {
+				if g.At(Pt(x, y)) == Rock {
+					t.Errorf("algorithm %d: lattice cell %v left uncarved", algo, Pt(x, y))
+				}
+			}
+		}
+		for y := 0; y < g.Size.Y; y++ {
+			for x := 0; x < g.Size.X; x++ {
+				edge := x == 0 || y == 0 || x == g.Size.X-1 || y == g.Size.Y-1
+				if m := g.At(Pt(x, y)); edge && m != Rock && m != Entrance && m != Exit {
+					t.Errorf("algorithm %d: outer wall cell %v carved", algo, Pt(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestUnicursal(t *testing.T) {
+	plain := testConfig(Pt(31, 21), 5)
+	plain.Algorithm = Unicursal
+	circle := testConfig(Pt(41, 41), 5)
+	circle.Algorithm, circle.Shape = Unicursal, Circle
+
+	for _, cfg := range []Config{plain, circle} {
+		g, entrance, exit := mustGenerate(t, cfg)
+		inside := cfg.mask()
+
+		cells := 0
+		var ends []Point
+		for y := 0; y < g.Size.Y; y++ {
+			for x := 0; x < g.Size.X; x++ {
+				p := Pt(x, y)
+				if !passable(g.At(p)) {
+					continue
+				}
+				cells++
+				if inside != nil && !inside(p) {
+					t.Errorf("shape %d: cell %v outside the shape is carved", cfg.Shape, p)
+				}
+				switch n := len(g.passages(p)); {
+				case n > 2:
+					t.Errorf("shape %d: cell %v has %d passages, want at most 2", cfg.Shape, p, n)
+				case n < 2:
+					ends = append(ends, p)
+				}
+			}
+		}
+		if len(ends) != 2 || !(ends[0] == entrance && ends[1] == exit || ends[0] == exit && ends[1] == entrance) {
+			t.Errorf("shape %d: ends at %v, want the entrance %v and exit %v", cfg.Shape, ends, entrance, exit)
+		}
+
+		if path, ok := g.Solve(entrance, exit); !ok || len(path) != cells {
+			t.Errorf("shape %d: Solve(%v, %v) has %d cells, want all %d", cfg.Shape, entrance, exit, len(path), cells)
+		}
+	}
+}
+
+func TestGenerateInto(t *testing.T) {
+	cfg := testConfig(Pt(41, 31), 15)
+	want, wantIn, wantOut := mustGenerate(t, cfg)
+
+	g := newGrid(cfg.Size)
+	other := cfg
+	other.Seed, other.Shape = 99, Circle
+	for _, c := range []Config{other, cfg} {
+		in, out, err := GenerateInto(context.Background(), g, c, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Seed == cfg.Seed && (in != wantIn || out != wantOut) {
+			t.Errorf("GenerateInto ends %v, %v; Generate gave %v, %v", in, out, wantIn, wantOut)
+		}
+	}
+	if !reflect.DeepEqual(g.g, want.g) || !reflect.DeepEqual(g.regions, want.regions) ||
+		g.regCount != want.regCount || !reflect.DeepEqual(g.rooms, want.rooms) {
+		t.Error("GenerateInto into a used grid differs from Generate")
+	}
+
+	if _, _, err := GenerateInto(context.Background(), newGrid(Pt(41, 41)), cfg, nil); err == nil {
+		t.Error("GenerateInto accepted a grid of the wrong size")
+	}
+}
+
+func TestRegrowCorridors(t *testing.T) {
+	cfg := testConfig(Pt(41, 31), 21)
+	g, _, _ := mustGenerate(t, cfg)
+	before := cloneGrid(g)
+	rooms := append([]image.Rectangle(nil), g.rooms...)
+
+	other := cfg
+	other.Seed, other.Algorithm, other.WindingPercent = 22, Backtracker, 20
+	if _, _, err := RegrowCorridors(context.Background(), g, other, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g.rooms, rooms) {
+		t.Errorf("rooms %v after regrowing, want %v", g.rooms, rooms)
+	}
+	changed := 0
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			p := Pt(x, y)
+			if g.inRoom(p) && !passable(g.At(p)) {
+				t.Errorf("room cell %v is %d after regrowing", p, g.At(p))
+			}
+			if !g.inRoom(p) && g.At(p) != before.At(p) {
+				changed++
+			}
+		}
+	}
+	if changed == 0 {
+		t.Error("regrowing with another seed and algorithm left the corridors as they were")
+	}
+	if err := g.Check(); err != nil {
+		t.Error(err)
+	}
+	if !g.IsFullyConnected(Moves{}) {
+		t.Error("maze is not fully connected after regrowing")
+	}
+
+	regrown := cloneGrid(g)
+	if _, _, err := RegrowCorridors(context.Background(), g, other, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !sameMaze(g, regrown) {
+		t.Error("regrowing again with the same config differs")
+	}
+
+	cfg.Algorithm = Unicursal
+	if _, _, err := RegrowCorridors(context.Background(), g, cfg, nil); err == nil {
+		t.Error("RegrowCorridors accepted the Unicursal algorithm")
+	}
+}
+
+// straightCells counts the passable cells whose two passages lie opposite
+// each other.
+func straightCells(g *Grid) int {
+	n := 0
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			ps := g.passages(Pt(x, y))
+			if passable(g.At(Pt(x, y))) && len(ps) == 2 && (ps[0].X == ps[1].X || ps[0].Y == ps[1].Y) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestWindingPercent(t *testing.T) {
+	cfg := testConfig(Pt(61, 61), 3)
+	cfg.Rooms.Tries = 0
+	twisty, _, _ := mustGenerate(t, cfg)
+	cfg.WindingPercent = 0
+	straight, _, _ := mustGenerate(t, cfg)
+
+	if s, w := straightCells(straight), straightCells(twisty); s <= w {
+		t.Errorf("winding 0 made %d straight cells, winding 100 made %d; want more", s, w)
+	}
+}
+
+func TestMaxCorridorRun(t *testing.T) {
+	const limit = 6
+	cfg := testConfig(Pt(41, 41), 4)
+	cfg.MaxCorridorRun = limit
+	g := newGrid(cfg.Size)
+	if err := growMaze(context.Background(), g, cfg, rand.New(rand.NewSource(cfg.Seed)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			end := Pt(x, y)
+			if !passable(g.At(end)) || len(g.passages(end)) == 2 {
+				continue
+			}
+			for _, next := range g.passages(end) {
+				prev, cur, run := end, next, 1
+				for ps := g.passages(cur); len(ps) == 2; ps = g.passages(cur) {
+					if ps[0] == prev {
+						prev, cur = cur, ps[1]
+					} else {
+						prev, cur = cur, ps[0]
+					}
+					run++
+				}
+				if run > limit {
+					t.Errorf("corridor from %v runs %d steps, want at most %d", end, run, limit)
+				}
+			}
+		}
+	}
+}
+
+// cycles returns the cyclomatic number of the passage graph: the number of
+// independent loops.
+func cycles(g *Grid) int {
+	cells, edges := 0, 0
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if passable(g.At(Pt(x, y))) {
+				cells++
+				edges += len(g.passages(Pt(x, y)))
+			}
+		}
+	}
+	_, components := g.Components(Moves{})
+	return edges/2 - cells + components
+}
+
+func TestExtraConnectors(t *testing.T) {
+	cfg := testConfig(Pt(61, 61), 5)
+	base, _, _ := mustGenerate(t, cfg)
+	cfg.ExtraConnectors = 10
+	loopy, _, _ := mustGenerate(t, cfg)
+
+	if got, want := cycles(loopy), cycles(base)+10; got != want {
+		t.Errorf("%d loops with 10 extra connectors, want %d", got, want)
+	}
+	if !loopy.IsFullyConnected(Moves{}) {
+		t.Error("maze with extra connectors is not fully connected")
+	}
+}
+
+// nearestSpread returns the variance of the distance from each room's center
+// to the nearest other room's center.
+func nearestSpread(rooms []image.Rectangle) float64 {
+	nearest := make([]float64, len(rooms))
+	for i, a := range rooms {
+		nearest[i] = math.Inf(1)
+		for j, b := range rooms {
+			if i != j {
+				d := roomCenter(a).Sub(roomCenter(b).Point)
+				nearest[i] = math.Min(nearest[i], math.Hypot(float64(d.X), float64(d.Y)))
+			}
+		}
+	}
+	mean, variance := 0.0, 0.0
+	for _, d := range nearest {
+		mean += d / float64(len(nearest))
+	}
+	for _, d := range nearest {
+		variance += (d - mean) * (d - mean) / float64(len(nearest))
+	}
+	return variance
+}
+
+func TestPoissonPlacementSpread(t *testing.T) {
+	params := RoomParams{Min: Pt(3, 3), Max: Pt(7, 7), Tries: 30}
+	allowed := func(Point) bool { return true }
+	clip := image.Rect(0, 0, 81, 81)
+	uniform, poisson := 0.0, 0.0
+	for seed := int64(0); seed < 20; seed++ {
+		rooms, _, err := createRooms(context.Background(), clip, params, params.Tries, allowed, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		uniform += nearestSpread(rooms)
+		rooms, _, err = createRoomsPoisson(context.Background(), clip, params, params.Tries, allowed, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		poisson += nearestSpread(rooms)
+	}
+
+	if poisson >= uniform {
+		t.Errorf("nearest-neighbour variance %.1f with Poisson-disk placement, %.1f with uniform; want lower", poisson/20, uniform/20)
+	}
+}
+
+func TestCreateRooms(t *testing.T) {
+	params := RoomParams{Min: Pt(3, 3), Max: Pt(9, 9), Tries: 50}
+	allowed := func(Point) bool { return true }
+	clip := image.Rect(0, 0, 61, 41)
+	for seed := int64(0); seed < 10; seed++ {
+		rooms, _, err := createRooms(context.Background(), clip, params, params.Tries, allowed, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rooms) == 0 {
+			t.Errorf("seed %d: no rooms placed", seed)
+		}
+		for i, r := range rooms {
+			if r.Empty() {
+				t.Errorf("seed %d: room %d is empty", seed, i)
+			}
+			if !r.In(clip.Inset(1)) {
+				t.Errorf("seed %d: room %v is not inside %v", seed, r, clip.Inset(1))
+			}
+			for _, other := range rooms[i+1:] {
+				if r.Overlaps(other) {
+					t.Errorf("seed %d: rooms %v and %v overlap", seed, r, other)
+				}
+			}
+		}
+	}
+}
+
+func TestRoomTypes(t *testing.T) {
+	types := map[string]RoomType{
+		"closet": {Weight: 1, Min: Pt(1, 1), Max: Pt(3, 3), MaxDoors: 1},
+		"vault":  {Weight: 1, Min: Pt(3, 3), Max: Pt(5, 5), MaxDoors: 1},
+		"hall":   {Weight: 2, Min: Pt(7, 3), Max: Pt(11, 5)},
+	}
+
+	// On a wide clip with few tries, hardly any room is dropped for
+	// overlapping, so the types kept follow the weights.
+	params := RoomParams{Tries: 400, Types: types}
+	allowed := func(Point) bool { return true }
+	rooms, kinds, err := createRooms(context.Background(), image.Rect(0, 0, 801, 801), params, params.Tries, allowed, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := make(map[string]int)
+	for i, r := range rooms {
+		ty := types[kinds[i]]
+		if r.Dx() < ty.Min.X || r.Dx() > ty.Max.X || r.Dy() < ty.Min.Y || r.Dy() > ty.Max.Y {
+			t.Errorf("%s %v is outside %v..%v", kinds[i], r, ty.Min, ty.Max)
+		}
+		count[kinds[i]]++
+	}
+	for name, ty := range types {
+		got, want := float64(count[name])/float64(len(rooms)), ty.Weight/4
+		if math.Abs(got-want) > 0.06 {
+			t.Errorf("%.2f of the rooms are %ss, want about %.2f", got, name, want)
+		}
+	}
+
+	for seed := int64(1); seed <= 10; seed++ {
+		cfg := testConfig(Pt(61, 41), seed)
+		cfg.Rooms.Types = types
+		cfg.ExtraConnectors = 20
+		g, _, _ := mustGenerate(t, cfg)
+		if err := g.Check(); err != nil {
+			t.Fatalf("seed %d: %s", seed, err)
+		}
+		if len(g.RoomTypes()) != len(g.Rooms()) {
+			t.Fatalf("seed %d: %d room types for %d rooms", seed, len(g.RoomTypes()), len(g.Rooms()))
+		}
+		for i, r := range g.Rooms() {
+			ty := types[g.RoomTypes()[i]]
+			if doors := len(roomOpenings(g, r)); ty.MaxDoors > 0 && doors > ty.MaxDoors {
+				t.Errorf("seed %d: %s %v has %d doors, want at most %d", seed, g.RoomTypes()[i], r, doors, ty.MaxDoors)
+			}
+		}
+	}
+
+	cfg := testConfig(Pt(61, 41), 1)
+	cfg.Rooms.Types = map[string]RoomType{"vault": {Weight: 1, Min: Pt(4, 4), Max: Pt(4, 4)}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("room type with no odd size validated")
+	}
+}
+
+func TestRoomsOnOddLattice(t *testing.T) {
+	for _, roominess := range []float64{0, 0.3, 3} {
+		cfg := testConfig(Pt(61, 41), 4)
+		cfg.Rooms = RoomParams{Min: Pt(2, 3), Max: Pt(10, 8), Tries: 50, Roominess: roominess}
+		g, _, _ := mustGenerate(t, cfg)
+		if len(g.Rooms()) == 0 {
+			t.Errorf("roominess %v: no rooms placed", roominess)
+		}
+		for _, r := range g.Rooms() {
+			if r.Dx()%2 != 1 || r.Dy()%2 != 1 || r.Min.X%2 != 1 || r.Min.Y%2 != 1 {
+				t.Errorf("roominess %v: room %v is off the odd lattice", roominess, r)
+			}
+		}
+	}
+}
+
+func TestNonSquareSizes(t *testing.T) {
+	for _, size := range []Point{Pt(41, 81), Pt(81, 41), Pt(40, 80), Pt(30, 11)} {
+		g, _, _ := mustGenerate(t, testConfig(size, 2))
+		if err := g.Check(); err != nil {
+			t.Errorf("%v: %s", size, err)
+		}
+		inner := g.Bounds().Inset(1)
+		carved := 0
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				p := Pt(x, y)
+				if m := g.At(p); m == Carved || m == Door {
+					carved++
+					if !p.In(inner) {
+						t.Errorf("%v: cell %v is carved outside %v", size, p, inner)
+					}
+				}
+			}
+		}
+		if carved == 0 {
+			t.Errorf("%v: nothing carved", size)
+		}
+	}
+}
+
+func TestRoomsSpanWideGrid(t *testing.T) {
+	cfg := testConfig(Pt(201, 21), 6)
+	cfg.Rooms.Tries = 100
+	g, _, _ := mustGenerate(t, cfg)
+
+	left, right := cfg.Size.X, 0
+	for _, r := range g.Rooms() {
+		left, right = min(left, r.Min.X), max(right, r.Max.X)
+	}
+	if left > cfg.Size.X/4 || right < cfg.Size.X*3/4 {
+		t.Errorf("rooms span x %d..%d of a %d-wide grid, want the full width", left, right, cfg.Size.X)
+	}
+}
+
+func TestRegions(t *testing.T) {
+	steps := 0
+	check := func(g *Grid) {
+		steps++
+		present := make(map[Region]bool)
+		for y := 0; y < g.Size.Y; y++ {
+			for x := 0; x < g.Size.X; x++ {
+				present[g.RegionAt(Pt(x, y))] = true
+			}
+		}
+		for _, r := range g.Regions() {
+			if r == NoRegion || !present[r] {
+				t.Fatalf("step %d: Regions() lists %d, which no cell has", steps, r)
+			}
+			delete(present, r)
+		}
+		delete(present, NoRegion)
+		if len(present) > 0 {
+			t.Fatalf("step %d: Regions() misses %v", steps, present)
+		}
+	}
+
+	if _, _, _, err := Generate(context.Background(), testConfig(Pt(31, 21), 8), check); err != nil {
+		t.Fatal(err)
+	}
+	if steps == 0 {
+		t.Fatal("Generate never reported a step")
+	}
+}
+
+func TestGenerateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	steps := 0
+	start := time.Now()
+	_, _, _, err := Generate(ctx, testConfig(Pt(1001, 1001), 1), func(*Grid) { steps++ })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Generate with a canceled context returned %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second || steps > 0 {
+		t.Errorf("Generate with a canceled context took %v and %d steps", elapsed, steps)
+	}
+}
+
+func TestFixedRooms(t *testing.T) {
+	boss, hall := image.Rect(21, 11, 32, 20), image.Rect(1, 1, 8, 6)
+
+	cfg := testConfig(Pt(41, 31), 9)
+	cfg.Rooms.Fixed = []image.Rectangle{boss, hall}
+	g, _, _ := mustGenerate(t, cfg)
+	rooms := g.Rooms()
+	if len(rooms) <= 2 || rooms[0] != boss || rooms[1] != hall {
+		t.Errorf("Rooms() = %v, want %v and %v followed by random rooms", rooms, boss, hall)
+	}
+	for _, r := range cfg.Rooms.Fixed {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if !passable(g.At(Pt(x, y))) {
+					t.Errorf("fixed room %v: cell %v not carved", r, Pt(x, y))
+				}
+			}
+		}
+	}
+
+	cfg.Rooms.Tries = 0
+	if g, _, _ = mustGenerate(t, cfg); len(g.Rooms()) != 2 {
+		t.Errorf("with no tries, Rooms() = %v, want only the fixed rooms", g.Rooms())
+	}
+
+	overlapping, outside := image.Rect(23, 13, 28, 18), image.Rect(37, 1, 44, 6)
+	cfg.Rooms.Fixed = []image.Rectangle{boss, overlapping, outside}
+	_, _, _, err := Generate(context.Background(), cfg, nil)
+	if err == nil {
+		t.Fatal("Generate with bad fixed rooms succeeded")
+	}
+	for _, r := range []image.Rectangle{overlapping, outside} {
+		if !strings.Contains(err.Error(), r.String()) {
+			t.Errorf("error %q does not name rejected room %v", err, r)
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	cfg := testConfig(Pt(41, 41), 9)
+	inside := func(p Point) bool {
+		dx, dy := p.X-20, p.Y-20
+		return dx*dx+dy*dy <= 20*20
+	}
+	cfg.Mask = inside
+	g, _, _ := mustGenerate(t, cfg)
+
+	carved := 0
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if g.At(Pt(x, y)) == Rock {
+				continue
+			}
+			carved++
+			if !inside(Pt(x, y)) {
+				t.Errorf("cell %v outside the mask is carved", Pt(x, y))
+			}
+		}
+	}
+	if carved == 0 {
+		t.Error("nothing carved inside the mask")
+	}
+	if !g.IsFullyConnected(Moves{}) {
+		t.Error("masked maze is not fully connected")
+	}
+}
+
+func TestShapeCircle(t *testing.T) {
+	for _, size := range []Point{Pt(41, 41), Pt(60, 45)} {
+		cfg := testConfig(size, 12)
+		cfg.Shape = Circle
+		g, _, _ := mustGenerate(t, cfg)
+
+		cx, cy := float64(size.X-1)/2, float64(size.Y-1)/2
+		r := float64(min(size.X, size.Y)-1) / 2
+		carved := 0
+		for y := 0; y < g.Size.Y; y++ {
+			for x := 0; x < g.Size.X; x++ {
+				if g.At(Pt(x, y)) == Rock {
+					continue
+				}
+				carved++
+				if d := math.Hypot(float64(x)-cx, float64(y)-cy); d > r {
+					t.Errorf("%v: cell %v carved %.1f from the center, outside radius %.1f", size, Pt(x, y), d, r)
+				}
+			}
+		}
+		if carved == 0 {
+			t.Errorf("%v: nothing carved inside the circle", size)
+		}
+		if !g.IsFullyConnected(Moves{}) {
+			t.Errorf("%v: circular maze is not fully connected", size)
+		}
+	}
+}
+
+func TestShapePolygon(t *testing.T) {
+	cfg := testConfig(Pt(61, 51), 13)
+	cfg.Shape = Polygon
+	cfg.Polygon = []Point{Pt(30, 0), Pt(60, 50), Pt(0, 50)}
+	g, _, _ := mustGenerate(t, cfg)
+
+	// Cells left of the left edge or right of the right edge, by the sign of
+	// their cross product with it.
+	outside := func(p Point) bool {
+		return 50*p.X+30*p.Y < 50*30 || 50*(p.X-30)-30*p.Y > 0
+	}
+	carved := 0
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if g.At(Pt(x, y)) == Rock {
+				continue
+			}
+			carved++
+			if outside(Pt(x, y)) || y >= 50 {
+				t.Errorf("cell %v outside the triangle is carved", Pt(x, y))
+			}
+		}
+	}
+	if carved == 0 {
+		t.Error("nothing carved inside the triangle")
+	}
+	if !g.IsFullyConnected(Moves{}) {
+		t.Error("triangular maze is not fully connected")
+	}
+
+	cfg.Polygon = cfg.Polygon[:2]
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate accepted a two-vertex polygon")
+	}
+}
+
+func TestDensity(t *testing.T) {
+	cfg := testConfig(Pt(61, 61), 10)
+	cfg.Rooms.Tries = 0
+	cfg.Density = func(p Point) float64 {
+		if p.X < 30 {
+			return 1
+		}
+		return 0.2
+	}
+	g := newGrid(cfg.Size)
+	if err := growMaze(context.Background(), g, cfg, rand.New(rand.NewSource(cfg.Seed)), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	dense, sparse := 0, 0
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if g.At(Pt(x, y)) == Carved && x < 30 {
+				dense++
+			} else if g.At(Pt(x, y)) == Carved && x > 30 {
+				sparse++
+			}
+		}
+	}
+	if dense <= 2*sparse {
+		t.Errorf("%d cells carved where density is 1, %d where it is 0.2; want far more in the first", dense, sparse)
+	}
+}
+
+func TestMinCorridorCoverage(t *testing.T) {
+	coverage := func(g *Grid) float64 {
+		index := roomIndex(g)
+		area, carved := 0, 0
+		for y := 0; y < g.Size.Y; y++ {
+			for x := 0; x < g.Size.X; x++ {
+				if index[y*g.Size.X+x] < 0 {
+					area++
+					if passable(g.At(Pt(x, y))) {
+						carved++
+					}
+				}
+			}
+		}
+		return float64(carved) / float64(area)
+	}
+
+	cfg := testConfig(Pt(61, 41), 11)
+	cfg.Density = func(Point) float64 { return 0.3 }
+	sparse, _, _ := mustGenerate(t, cfg)
+	cfg.MinCorridorCoverage = 0.33
+	if got := coverage(sparse); got >= cfg.MinCorridorCoverage {
+		t.Fatalf("corridors cover %.2f without a minimum; the test needs less than %.2f", got, cfg.MinCorridorCoverage)
+	}
+
+	g, _, _ := mustGenerate(t, cfg)
+	if got := coverage(g); got < cfg.MinCorridorCoverage {
+		t.Errorf("corridors cover %.2f, want at least %.2f", got, cfg.MinCorridorCoverage)
+	}
+	if err := g.Check(); err != nil {
+		t.Error(err)
+	}
+
+	cfg.MinCorridorCoverage = 0.9
+	if _, _, _, err := Generate(context.Background(), cfg, nil); err == nil {
+		t.Error("generating with corridors covering 0.9 of the maze succeeded")
+	}
+}
+
+func TestLeafExit(t *testing.T) {
+	leafRooms := 0
+	for seed := int64(1); seed <= 5; seed++ {
+		cfg := testConfig(Pt(41, 31), seed)
+		cfg.Exit = LeafExit
+		g, entrance, exit := mustGenerate(t, cfg)
+
+		dist := g.DistanceFrom(entrance, Moves{})
+		far := -1
+		for _, r := range g.rooms {
+			if entrance.In(r) || len(roomOpenings(g, r)) != 1 {
+				continue
+			}
+			for y := r.Min.Y; y < r.Max.Y; y++ {
+				for x := r.Min.X; x < r.Max.X; x++ {
+					far = max(far, dist[Pt(x, y)])
+				}
+			}
+		}
+		if far < 0 {
+			if !isDeadEnd(g, exit) {
+				t.Errorf("seed %d: no leaf room, but exit %v is not a dead end", seed, exit)
+			}
+			continue
+		}
+		leafRooms++
+		if !g.inRoom(exit) || len(roomOpenings(g, roomAt(g, exit))) != 1 {
+			t.Errorf("seed %d: exit %v is not in a leaf room", seed, exit)
+		}
+		if dist[exit] != far {
+			t.Errorf("seed %d: exit %d steps from the entrance, want the farthest leaf room cell at %d", seed, dist[exit], far)
+		}
+	}
+	if leafRooms == 0 {
+		t.Error("no maze had a leaf room")
+	}
+
+	// Without rooms the exit goes to the farthest dead end, even though the
+	// far side of the loop is farther.
+	g := parseGrid(
+		"#######",
+		"#     #",
+		"# ### #",
+		"#     #",
+		"### ###",
+		"#######",
+	)
+	if _, exit := placeEnds(g, LeafExit); exit != Pt(3, 4) {
+		t.Errorf("exit placed at %v, want the dead end at (3,4)", exit)
+	}
+
+	cfg := testConfig(Pt(21, 21), 1)
+	cfg.Exit = LeafExit + 1
+	if cfg.Validate() == nil {
+		t.Error("Validate accepted an unknown exit placement")
+	}
+}
+
+// roomAt returns the room of g that p lies in.
+func roomAt(g *Grid, p Point) image.Rectangle {
+	for _, r := range g.rooms {
+		if p.In(r) {
+			return r
+		}
+	}
+	return image.Rectangle{}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	for _, size := range []int{61, 121, 241} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			cfg := DefaultConfig(Pt(size, size))
+			for i := 0; i < b.N; i++ {
+				cfg.Seed = int64(i)
+				mustGenerate(b, cfg)
+			}
+		})
+	}
+}
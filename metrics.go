@@ -0,0 +1,214 @@
+package maze
+
+import "math"
+
+// LongestStraight finds the longest run of consecutive corridor cells, passable
+// cells outside every room, along a single row or column. It returns the first
+// cell of the run, the direction it extends in, and its length in cells.
+func LongestStraight(g *Grid) (Point, direction, int) {
+	bounds := g.Bounds()
+	var start Point
+	dir := Dir.Right
+	best := 0
+	corridor := func(p Point) bool {
+		return passable(g.At(p)) && !g.inRoom(p)
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			here := Pt(x, y)
+			if !corridor(here) {
+				continue
+			}
+			for _, d := range []direction{Dir.Right, Dir.Down} {
+				prev := here.AddDir(d.Reverse())
+				if corridor(prev) {
+					continue
+				}
+				n := 0
+				for p := here; corridor(p); p = p.AddDir(d) {
+					n++
+				}
+				if n > best {
+					start, dir, best = here, d, n
+				}
+			}
+		}
+	}
+
+	return start, dir, best
+}
+
+// Complexity measures how varied cell connectivity is as the Shannon entropy,
+// in bits, of the degree histogram of carved cells:
+//
+//	H = -Σ p(k) log2 p(k)
+//
+// where p(k) is the fraction of carved cells with exactly k carved orthogonal
+// neighbours, k = 0..4. A grid where every cell has the same degree scores 0;
+// the maximum, log2(5), is reached when all five degrees are equally common.
+// Corridor-dominated mazes score low, mazes mixing dead ends, corridors and
+// junctions score high.
+func Complexity(g *Grid) float64 {
+	bounds := g.Bounds()
+	var hist [5]int
+	total := 0
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			here := Pt(x, y)
+			if !passable(g.At(here)) {
+				continue
+			}
+			hist[len(g.passages(here))]++
+			total++
+		}
+	}
+
+	h := 0.0
+	for _, n := range hist {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / float64(total)
+		h -= p * math.Log2(p)
+	}
+
+	return h
+}
+
+// BoxCountingDimension estimates the fractal dimension of the carved cells.
+// The grid is covered with s×s boxes for s = 1, 2, 4, ... up to half the
+// shorter side, and N(s), the number of boxes holding at least one carved
+// cell, is counted at each scale. The estimate is the least-squares slope of
+// log N(s) against log(1/s). It returns 0 when there is nothing carved or the
+// grid is too small for two scales.
+func BoxCountingDimension(g *Grid) float64 {
+	var xs, ys []float64
+
+	for s := 1; s <= min(g.Size.X, g.Size.Y)/2; s *= 2 {
+		boxes := make(map[Point]bool)
+		for y := 0; y < g.Size.Y; y++ {
+			for x := 0; x < g.Size.X; x++ {
+				if passable(g.At(Pt(x, y))) {
+					boxes[Pt(x/s, y/s)] = true
+				}
+			}
+		}
+		if len(boxes) == 0 {
+			return 0
+		}
+		xs = append(xs, math.Log(1/float64(s)))
+		ys = append(ys, math.Log(float64(len(boxes))))
+	}
+
+	if len(xs) < 2 {
+		return 0
+	}
+
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx /= float64(len(xs))
+	my /= float64(len(ys))
+
+	var num, den float64
+	for i := range xs {
+		num += (xs[i] - mx) * (ys[i] - my)
+		den += (xs[i] - mx) * (xs[i] - mx)
+	}
+
+	return num / den
+}
+
+// EntranceFairness scores how evenly the entrances are placed relative to
+// goal as 1 - (longest - shortest) / longest over their shortest-path
+// distances to it. Equidistant entrances score 1; the score drops toward 0 as
+// one entrance gets a head start. It is 0 if any entrance cannot reach goal.
+func EntranceFairness(g *Grid, entrances []Point, goal Point) float64 {
+	dist := g.DistanceFrom(goal, Moves{})
+	shortest, longest := -1, 0
+
+	for _, e := range entrances {
+		d, ok := dist[e]
+		if !ok {
+			return 0
+		}
+		if shortest < 0 || d < shortest {
+			shortest = d
+		}
+		longest = max(longest, d)
+	}
+
+	if longest == 0 {
+		return 1
+	}
+
+	return 1 - float64(longest-shortest)/float64(longest)
+}
+
+// Stats summarizes a maze for comparing generation settings.
+type Stats struct {
+	Carved    int // passable cells
+	DeadEnds  int // passable cells with one passable neighbour
+	Junctions int // passable cells outside rooms with three or more passable neighbours
+	Rooms     int
+	Regions   int // distinct regions among passable cells
+	// Components is the number of separate connected areas; 1 for a
+	// connected maze.
+	Components int
+	// Diameter is the longest shortest path, in steps, within any one
+	// component, found by double breadth-first search.
+	Diameter    int
+	LongestHall int // longest straight run of corridor cells
+	Chokepoints int // cells whose removal would split their component
+}
+
+// Stats measures the maze.
+func (g *Grid) Stats() Stats {
+	s := Stats{Rooms: len(g.rooms)}
+	regions := make(map[Region]bool)
+
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			p := Pt(x, y)
+			if !passable(g.At(p)) {
+				continue
+			}
+			s.Carved++
+			regions[g.RegionAt(p)] = true
+			if isDeadEnd(g, p) {
+				s.DeadEnds++
+			}
+			if isJunction(g, p) {
+				s.Junctions++
+			}
+		}
+	}
+	s.Regions = len(regions)
+
+	labels, count := g.Components(Moves{})
+	s.Components = count
+	seeds := make(map[int]Point)
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if c, ok := labels[Pt(x, y)]; ok {
+				if _, seen := seeds[c]; !seen {
+					seeds[c] = Pt(x, y)
+				}
+			}
+		}
+	}
+	for _, seed := range seeds {
+		end, _ := g.farthest(seed)
+		_, d := g.farthest(end)
+		s.Diameter = max(s.Diameter, d)
+	}
+
+	_, _, s.LongestHall = LongestStraight(g)
+	s.Chokepoints = len(Chokepoints(g))
+
+	return s
+}
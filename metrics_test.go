@@ -0,0 +1,110 @@
+package maze
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestStatsJunctions(t *testing.T) {
+	g, _, _ := mustGenerate(t, testConfig(Pt(61, 61), 0))
+	s := g.Stats()
+	if s.Junctions != len(g.Junctions()) {
+		t.Errorf("Stats().Junctions = %d, Junctions() returns %d", s.Junctions, len(g.Junctions()))
+	}
+	for _, p := range g.Junctions() {
+		if g.inRoom(p) {
+			t.Errorf("junction %v lies in a room", p)
+		}
+	}
+}
+
+func TestLongestStraight(t *testing.T) {
+	g := parseGrid(
+		"###########",
+		"#     #   #",
+		"# ### # # #",
+		"#   #   # #",
+		"### ##### #",
+		"#         #",
+		"###########",
+	)
+	start, dir, n := LongestStraight(g)
+	if start != Pt(1, 5) || *dir.Point != *Dir.Right.Point || n != 9 {
+		t.Errorf("LongestStraight = %v, %v, %d; want %v, %v, 9", start, dir.Point, n, Pt(1, 5), Dir.Right.Point)
+	}
+
+	// A room spanning the bottom row doesn't count as corridor.
+	g.rooms = append(g.rooms, image.Rect(1, 5, 10, 6))
+	start, dir, n = LongestStraight(g)
+	if start != Pt(1, 1) || *dir.Point != *Dir.Right.Point || n != 5 {
+		t.Errorf("LongestStraight with a room = %v, %v, %d; want %v, %v, 5", start, dir.Point, n, Pt(1, 1), Dir.Right.Point)
+	}
+	if got := g.Stats().LongestHall; got != 5 {
+		t.Errorf("Stats().LongestHall = %d, want 5", got)
+	}
+}
+
+func TestComplexity(t *testing.T) {
+	ring := parseGrid(
+		"#####",
+		"#   #",
+		"# # #",
+		"#   #",
+		"#####",
+	)
+	if got := Complexity(ring); got != 0 {
+		t.Errorf("Complexity of a ring = %g, want 0", got)
+	}
+
+	line := parseGrid(
+		"############",
+		"#          #",
+		"############",
+	)
+	maze, _, _ := mustGenerate(t, testConfig(Pt(61, 61), 22))
+	low, high := Complexity(line), Complexity(maze)
+	if low <= 0 || low >= 1 {
+		t.Errorf("Complexity of a straight corridor = %g, want just above 0", low)
+	}
+	if high <= low || high > math.Log2(5) {
+		t.Errorf("Complexity of a generated maze = %g, want between %g and log2(5)", high, low)
+	}
+}
+
+func TestBoxCountingDimension(t *testing.T) {
+	maze, _, _ := mustGenerate(t, testConfig(Pt(129, 129), 26))
+	if d := BoxCountingDimension(maze); d < 1.5 || d > 2.05 {
+		t.Errorf("BoxCountingDimension of a dense maze = %.2f, want between 1.5 and 2", d)
+	}
+
+	line := newGrid(Pt(129, 129))
+	for x := 1; x < 128; x++ {
+		line.SetMaterial(Pt(x, 64), Carved)
+	}
+	if d := BoxCountingDimension(line); math.Abs(d-1) > 0.1 {
+		t.Errorf("BoxCountingDimension of a straight line = %.2f, want about 1", d)
+	}
+	if d := BoxCountingDimension(newGrid(Pt(129, 129))); d != 0 {
+		t.Errorf("BoxCountingDimension with nothing carved = %g, want 0", d)
+	}
+}
+
+func TestEntranceFairness(t *testing.T) {
+	g := parseGrid(
+		"#############",
+		"#           #",
+		"#############",
+	)
+	goal := Pt(6, 1)
+	if got := EntranceFairness(g, []Point{Pt(1, 1), Pt(11, 1)}, goal); got != 1 {
+		t.Errorf("EntranceFairness of equidistant entrances = %g, want 1", got)
+	}
+	if got := EntranceFairness(g, []Point{Pt(1, 1), Pt(5, 1)}, goal); got > 0.25 {
+		t.Errorf("EntranceFairness of skewed entrances = %g, want at most 0.25", got)
+	}
+	g.SetMaterial(Pt(8, 1), Rock)
+	if got := EntranceFairness(g, []Point{Pt(1, 1), Pt(11, 1)}, goal); got != 0 {
+		t.Errorf("EntranceFairness with an entrance cut off = %g, want 0", got)
+	}
+}
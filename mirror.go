@@ -0,0 +1,102 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"image"
+)
+
+// GenerateMirrored builds a cfg.Size maze for two players out of one half and
+// its reflection. The left half, up to the center column, is made by Generate
+// from cfg and copied, reflected, into the right half, where its regions get
+// ids of their own past the left half's. A shared corridor, a region of its
+// own, then joins the halves across the center along the row on which the
+// left half reaches closest to it, the row nearest the middle if several do.
+// The Entrance is the cell of the left half farthest from that corridor and
+// the Exit its reflection, so each player has the same way to the center.
+// cfg.Mask, cfg.Shape and cfg.Density are consulted in whole-maze coordinates
+// for the left half, and cfg.Rooms.Fixed must lie in it; the right half is
+// its reflection whatever they say of it. cfg.Exit is ignored. Errors from
+// Generate are returned, as is an error if the shared corridor would cross a
+// masked cell.
+func GenerateMirrored(ctx context.Context, cfg Config) (grid *Grid, entrance, exit Point, err error) {
+	size, mask := cfg.Size, cfg.mask()
+	center := (size.X - 1) / 2
+	if center < 2 {
+		return nil, entrance, exit, fmt.Errorf("maze: width %d is too narrow to mirror", size.X)
+	}
+	cfg.Size, cfg.Shape, cfg.Polygon = Pt(center+1, size.Y), Rectangle, nil
+	if mask != nil {
+		cfg.Mask = mask
+	}
+	half, in, out, err := Generate(ctx, cfg, nil)
+	if err != nil {
+		return nil, entrance, exit, err
+	}
+	for _, end := range []Point{in, out} {
+		if half.At(end) != Rock {
+			half.SetMaterial(end, Carved)
+		}
+	}
+
+	grid = newGrid(size)
+	mirror := func(p Point) Point { return Pt(size.X-1-p.X, p.Y) }
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < center; x++ {
+			p := Pt(x, y)
+			grid.SetMaterial(p, half.At(p))
+			grid.SetMaterial(mirror(p), half.At(p))
+			if r := half.RegionAt(p); r != NoRegion {
+				grid.SetRegion(p, r)
+				grid.SetRegion(mirror(p), r+half.regCount)
+			}
+		}
+	}
+	grid.regCount = 2 * half.regCount
+	grid.rooms = append(grid.rooms, half.rooms...)
+	grid.roomTypes = append(grid.roomTypes, half.roomTypes...)
+	for i, r := range half.rooms {
+		grid.rooms = append(grid.rooms, image.Rect(size.X-r.Max.X, r.Min.Y, size.X-r.Min.X, r.Max.Y))
+		grid.roomTypes = append(grid.roomTypes, half.roomTypes[i])
+	}
+
+	// reach is the column of the passable cell closest to the center, and row
+	// the row it is on.
+	row, reach, mid := -1, -1, size.Y/2
+	for y := 1; y < size.Y-1; y++ {
+		for x := center - 1; x >= max(reach, 0); x-- {
+			if passable(half.At(Pt(x, y))) {
+				if x > reach || abs(y-mid) < abs(row-mid) {
+					row, reach = y, x
+				}
+				break
+			}
+		}
+	}
+	if reach < 0 {
+		return nil, entrance, exit, fmt.Errorf("maze: nothing carved to mirror")
+	}
+	corridor := grid.NewRegion()
+	for x := reach + 1; x < size.X-1-reach; x++ {
+		p := Pt(x, row)
+		if mask != nil && !mask(p) {
+			return nil, entrance, exit, fmt.Errorf("maze: can't join the mirrored halves at %v", p)
+		}
+		grid.SetMaterial(p, Carved)
+		grid.SetRegion(p, corridor)
+	}
+
+	far := -1
+	dist := grid.DistanceFrom(Pt(reach+1, row), Moves{})
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < center; x++ {
+			if d, ok := dist[Pt(x, y)]; ok && d > far {
+				entrance, far = Pt(x, y), d
+			}
+		}
+	}
+	exit = mirror(entrance)
+	grid.SetMaterial(entrance, Entrance)
+	grid.SetMaterial(exit, Exit)
+	return grid, entrance, exit, nil
+}
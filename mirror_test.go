@@ -0,0 +1,80 @@
+package maze
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGenerateMirrored(t *testing.T) {
+	for _, size := range []Point{Pt(41, 31), Pt(40, 31), Pt(43, 21)} {
+		cfg := testConfig(size, 8)
+		g, entrance, exit, err := GenerateMirrored(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("%v: %s", size, err)
+		}
+		if err := g.Check(); err != nil {
+			t.Errorf("%v: %s", size, err)
+		}
+		if !g.IsFullyConnected(Moves{}) {
+			t.Errorf("%v: mirrored maze is not fully connected", size)
+		}
+
+		mirror := func(p Point) Point { return Pt(size.X-1-p.X, p.Y) }
+		if exit != mirror(entrance) {
+			t.Errorf("%v: exit %v does not mirror entrance %v", size, exit, entrance)
+		}
+		// left maps every region on the left to the one mirroring it. Only
+		// the shared corridor mirrors itself.
+		left := make(map[Region]Region)
+		right := make(map[Region]bool)
+		shared := make(map[Region]bool)
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < (size.X-1)/2; x++ {
+				p, q := Pt(x, y), mirror(Pt(x, y))
+				a, b := g.At(p), g.At(q)
+				if a == Entrance && b == Exit {
+					continue
+				}
+				if a != b {
+					t.Fatalf("%v: %v is %d but its mirror %v is %d", size, p, a, q, b)
+				}
+				if a == Rock {
+					continue
+				}
+				ra, rb := g.RegionAt(p), g.RegionAt(q)
+				if r, ok := left[ra]; ok && r != rb {
+					t.Fatalf("%v: region %d mirrors both %d and %d", size, ra, r, rb)
+				}
+				if ra == rb {
+					shared[ra] = true
+					continue
+				}
+				left[ra] = rb
+				right[rb] = true
+			}
+		}
+		for r := range left {
+			if right[r] || shared[r] {
+				t.Errorf("%v: region %d is on both sides", size, r)
+			}
+		}
+		if len(shared) > 1 {
+			t.Errorf("%v: %d regions mirror themselves, want the shared corridor alone", size, len(shared))
+		}
+
+		path, ok := g.Solve(entrance, exit)
+		if !ok {
+			t.Fatalf("%v: no path from %v to %v", size, entrance, exit)
+		}
+		for i, p := range path {
+			if q := path[len(path)-1-i]; q != mirror(p) {
+				t.Errorf("%v: path is not symmetric: %v and %v", size, p, q)
+				break
+			}
+		}
+	}
+
+	if _, _, _, err := GenerateMirrored(context.Background(), testConfig(Pt(4, 21), 1)); err == nil {
+		t.Error("mirroring a maze 4 cells wide succeeded")
+	}
+}
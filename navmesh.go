@@ -0,0 +1,92 @@
+package maze
+
+import "image"
+
+// Navmesh splits the carved cells into non-overlapping rectangles usable as
+// convex navigation polygons. Open areas such as rooms are taken first, each
+// as the largest rectangle left; once only one-cell-wide areas remain, every
+// corridor is split into straight horizontal or vertical runs.
+func Navmesh(g *Grid) []image.Rectangle {
+	covered := make([]bool, len(g.g))
+	free := func(x, y int) bool {
+		i := y*g.Size.X + x
+		return !covered[i] && passable(g.g[i])
+	}
+	cover := func(r image.Rectangle) {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				covered[y*g.Size.X+x] = true
+			}
+		}
+	}
+
+	mesh := make([]image.Rectangle, 0)
+
+	for {
+		r := largestRect(g.Size, free)
+		if r.Dx() < 2 || r.Dy() < 2 {
+			break
+		}
+		cover(r)
+		mesh = append(mesh, r)
+	}
+
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if !free(x, y) {
+				continue
+			}
+			w := 1
+			for x+w < g.Size.X && free(x+w, y) {
+				w++
+			}
+			h := 1
+			if w == 1 {
+				for y+h < g.Size.Y && free(x, y+h) {
+					h++
+				}
+			}
+			r := image.Rect(x, y, x+w, y+h)
+			cover(r)
+			mesh = append(mesh, r)
+		}
+	}
+
+	return mesh
+}
+
+// largestRect finds the largest rectangle of free cells with the classic
+// histogram-and-stack scan, one row at a time.
+func largestRect(size Point, free func(x, y int) bool) image.Rectangle {
+	heights := make([]int, size.X+1)
+	var best image.Rectangle
+
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			if free(x, y) {
+				heights[x]++
+			} else {
+				heights[x] = 0
+			}
+		}
+
+		stack := make([]int, 0, size.X)
+		for x := 0; x <= size.X; x++ {
+			for len(stack) > 0 && heights[stack[len(stack)-1]] >= heights[x] {
+				h := heights[stack[len(stack)-1]]
+				stack = stack[:len(stack)-1]
+				left := 0
+				if len(stack) > 0 {
+					left = stack[len(stack)-1] + 1
+				}
+				r := image.Rect(left, y-h+1, x, y+1)
+				if h > 0 && r.Dx()*r.Dy() > best.Dx()*best.Dy() {
+					best = r
+				}
+			}
+			stack = append(stack, x)
+		}
+	}
+
+	return best
+}
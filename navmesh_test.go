@@ -0,0 +1,26 @@
+package maze
+
+import "testing"
+
+func TestNavmesh(t *testing.T) {
+	g, _, _ := mustGenerate(t, testConfig(Pt(61, 41), 28))
+	cover := make(map[Point]int)
+	for _, r := range Navmesh(g) {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if !passable(g.At(Pt(x, y))) {
+					t.Errorf("rectangle %v covers wall cell %v", r, Pt(x, y))
+				}
+				cover[Pt(x, y)]++
+			}
+		}
+	}
+
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if n := cover[Pt(x, y)]; passable(g.At(Pt(x, y))) && n != 1 {
+				t.Errorf("carved cell %v covered by %d rectangles, want 1", Pt(x, y), n)
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package maze
+
+// Pillars returns the rock cells whose eight neighbours are all passable.
+func Pillars(g *Grid) []Point {
+	bounds := g.Bounds()
+	pillars := make([]Point, 0)
+
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y++ {
+	Cells:
+		for x := bounds.Min.X + 1; x < bounds.Max.X-1; x++ {
+			here := Pt(x, y)
+			if g.At(here) != Rock {
+				continue
+			}
+			for _, d := range append(append([]direction{}, Dirs...), Diagonals...) {
+				if !passable(g.At(here.AddDir(d))) {
+					continue Cells
+				}
+			}
+			pillars = append(pillars, here)
+		}
+	}
+
+	return pillars
+}
+
+// RemovePillars carves out every pillar, giving it the region of the cell
+// above it. Unless inRooms is set, pillars inside or next to a room are left
+// standing, so only those in corridors go.
+func RemovePillars(g *Grid, inRooms bool) {
+	for _, p := range Pillars(g) {
+		if !inRooms && g.nearRoom(p) {
+			continue
+		}
+		g.SetMaterial(p, Carved)
+		g.SetRegion(p, g.RegionAt(p.AddDir(Dir.Up)))
+	}
+}
+
+// nearRoom reports whether p or any of its eight neighbours is in a room.
+func (g *Grid) nearRoom(p Point) bool {
+	if g.inRoom(p) {
+		return true
+	}
+	for _, d := range append(append([]direction{}, Dirs...), Diagonals...) {
+		if g.inRoom(p.AddDir(d)) {
+			return true
+		}
+	}
+	return false
+}
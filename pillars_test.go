@@ -0,0 +1,41 @@
+package maze
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+func TestRemovePillars(t *testing.T) {
+	build := func() *Grid {
+		g := parseGrid(
+			"##########",
+			"#   ##   #",
+			"# # ## # #",
+			"#   ##   #",
+			"##########",
+		)
+		g.rooms = append(g.rooms, image.Rect(1, 1, 4, 4))
+		return g
+	}
+	room, corridor := Pt(2, 2), Pt(7, 2)
+
+	g := build()
+	if got, want := Pillars(g), []Point{room, corridor}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Pillars = %v, want %v", got, want)
+	}
+
+	RemovePillars(g, false)
+	if g.At(room) != Rock {
+		t.Error("RemovePillars without inRooms removed the room pillar")
+	}
+	if g.At(corridor) != Carved || g.RegionAt(corridor) == NoRegion {
+		t.Error("RemovePillars did not carve the corridor pillar")
+	}
+
+	g = build()
+	RemovePillars(g, true)
+	if len(Pillars(g)) != 0 {
+		t.Errorf("RemovePillars with inRooms left %v", Pillars(g))
+	}
+}
@@ -0,0 +1,95 @@
+package maze
+
+import "math/rand"
+
+// DeadEnds returns the reachable Carved cells with exactly one passable
+// neighbour, in row-major order. The entrance and exit count as passable, so
+// the corridor cell leading to either is not a dead end.
+func (g *Grid) DeadEnds() []Point {
+	return g.floorWhere(func(p Point) bool { return isDeadEnd(g, p) })
+}
+
+// Junctions returns the reachable Carved cells that are junctions, in
+// row-major order.
+func (g *Grid) Junctions() []Point {
+	return g.floorWhere(func(p Point) bool { return isJunction(g, p) })
+}
+
+// isJunction reports whether p is a passable cell outside every room where
+// three or more passable cells meet. Room interiors are left out, since
+// nearly every cell of a room would count.
+func isJunction(g *Grid, p Point) bool {
+	return passable(g.At(p)) && !g.inRoom(p) && len(g.passages(p)) >= 3
+}
+
+// RoomFrontiers returns the rock cells orthogonally next to a room, the
+// places a door into it could go, in row-major order. The outer wall is left
+// out, since a door there would lead out of the maze.
+func (g *Grid) RoomFrontiers() []Point {
+	frontier := make([]Point, 0)
+	for y := 1; y < g.Size.Y-1; y++ {
+		for x := 1; x < g.Size.X-1; x++ {
+			p := Pt(x, y)
+			if g.At(p) != Rock || g.inRoom(p) {
+				continue
+			}
+			for _, d := range Dirs {
+				if g.inRoom(p.AddDir(d)) {
+					frontier = append(frontier, p)
+					break
+				}
+			}
+		}
+	}
+	return frontier
+}
+
+// SampleFloor returns n distinct reachable Carved cells picked at random with
+// rng, or all of them in random order if there are fewer than n.
+func (g *Grid) SampleFloor(n int, rng *rand.Rand) []Point {
+	floor := g.floorWhere(func(Point) bool { return true })
+	n = min(n, len(floor))
+	for i := 0; i < n; i++ {
+		j := i + rng.Intn(len(floor)-i)
+		floor[i], floor[j] = floor[j], floor[i]
+	}
+	return floor[:max(n, 0)]
+}
+
+func (g *Grid) inRoom(p Point) bool {
+	for _, r := range g.rooms {
+		if p.In(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// floorWhere returns the Carved cells, in row-major order, that keep passes
+// and that can be reached from the entrance, or from the first passable cell
+// if there is no entrance.
+func (g *Grid) floorWhere(keep func(Point) bool) []Point {
+	from, ok := g.firstPassable()
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if g.At(Pt(x, y)) == Entrance {
+				from, ok = Pt(x, y), true
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+
+	reach := g.DistanceFrom(from, Moves{})
+	ps := make([]Point, 0)
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			p := Pt(x, y)
+			if _, ok := reach[p]; ok && g.At(p) == Carved && keep(p) {
+				ps = append(ps, p)
+			}
+		}
+	}
+	return ps
+}
@@ -0,0 +1,72 @@
+package maze
+
+import (
+	"image"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestDeadEndsAndJunctions(t *testing.T) {
+	g := parseGrid(
+		"#########",
+		"#<      #",
+		"##### # #",
+		"#     #>#",
+		"#########",
+	)
+
+	if got, want := g.DeadEnds(), []Point{Pt(1, 3)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DeadEnds() = %v, want %v", got, want)
+	}
+	if got, want := g.Junctions(), []Point{Pt(5, 1)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Junctions() = %v, want %v", got, want)
+	}
+}
+
+func TestRoomFrontiers(t *testing.T) {
+	for _, c := range []struct {
+		room image.Rectangle
+		want int
+	}{
+		{image.Rect(3, 3, 6, 6), 12},
+		{image.Rect(1, 1, 4, 4), 6},
+	} {
+		g := newGrid(Pt(9, 9))
+		g.carveRoom(c.room, "")
+		frontier := g.RoomFrontiers()
+		if len(frontier) != c.want {
+			t.Errorf("room %v: RoomFrontiers() = %v, want %d cells", c.room, frontier, c.want)
+		}
+		for _, p := range frontier {
+			if g.At(p) != Rock || p.In(c.room) || !p.In(c.room.Inset(-1)) {
+				t.Errorf("room %v: frontier cell %v is not rock next to the room", c.room, p)
+			}
+		}
+	}
+}
+
+func TestSampleFloor(t *testing.T) {
+	g, _, _ := mustGenerate(t, testConfig(Pt(31, 21), 15))
+	a := g.SampleFloor(20, rand.New(rand.NewSource(1)))
+	b := g.SampleFloor(20, rand.New(rand.NewSource(1)))
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("SampleFloor differs for the same seed: %v and %v", a, b)
+	}
+
+	seen := make(map[Point]bool)
+	for _, p := range a {
+		if g.At(p) != Carved || seen[p] {
+			t.Errorf("SampleFloor returned %v, which is not a distinct carved cell", p)
+		}
+		seen[p] = true
+	}
+	if len(a) != 20 {
+		t.Errorf("SampleFloor(20) returned %d cells", len(a))
+	}
+
+	floor := g.floorWhere(func(Point) bool { return true })
+	if got := g.SampleFloor(len(floor)+5, rand.New(rand.NewSource(1))); len(got) != len(floor) {
+		t.Errorf("SampleFloor(%d) returned %d cells, want all %d", len(floor)+5, len(got), len(floor))
+	}
+}
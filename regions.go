@@ -0,0 +1,305 @@
+package maze
+
+import (
+	"image"
+	"sort"
+)
+
+// roomIndex returns, for every cell in row-major order, the index into
+// g.Rooms() of the room it lies in, or -1 outside every room.
+func roomIndex(g *Grid) []int {
+	index := make([]int, len(g.g))
+	for i := range index {
+		index[i] = -1
+	}
+	for i, r := range g.rooms {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if p := Pt(x, y); g.InBounds(p) {
+					index[p.Y*g.Size.X+p.X] = i
+				}
+			}
+		}
+	}
+	return index
+}
+
+// corridorReach returns the rooms, other than room from, that can be reached
+// from it through passable cells outside every room, in increasing order.
+func corridorReach(g *Grid, index []int, from int) []int {
+	seen := make(map[Point]bool)
+	found := make(map[int]bool)
+	queue := make([]Point, 0)
+	r := g.rooms[from]
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if p := Pt(x, y); g.InBounds(p) && passable(g.At(p)) {
+				seen[p] = true
+				queue = append(queue, p)
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, n := range g.passages(p) {
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+			if i := index[n.Y*g.Size.X+n.X]; i >= 0 && i != from {
+				found[i] = true
+			} else {
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	rooms := make([]int, 0, len(found))
+	for i := range found {
+		rooms = append(rooms, i)
+	}
+	sort.Ints(rooms)
+	return rooms
+}
+
+// CorridorOnlyReachable returns the indices into g.Rooms() of the rooms that
+// can be reached from room from along corridors alone, without crossing any
+// other room, in increasing order. Rooms are named by index rather than by
+// Region because connecting the maze merges every region into one, so a
+// finished maze's rooms all share the same Region.
+func (g *Grid) CorridorOnlyReachable(from int) []int {
+	return corridorReach(g, roomIndex(g), from)
+}
+
+// DominatingRooms picks a set of rooms, as indices into g.Rooms() like those
+// of CorridorOnlyReachable, such that every room is in the set or joined to
+// one in it by corridors that cross no other room. It uses the greedy approximation: repeatedly take the room that
+// covers the most rooms not yet covered, lowest index first on ties.
+func DominatingRooms(g *Grid) []int {
+	index := roomIndex(g)
+	graph := make([][]int, len(g.rooms))
+	for i := range graph {
+		graph[i] = corridorReach(g, index, i)
+	}
+
+	covered := make([]bool, len(graph))
+	left := len(graph)
+	set := make([]int, 0)
+
+	for left > 0 {
+		best, gain := 0, -1
+		for r := range graph {
+			n := 0
+			if !covered[r] {
+				n++
+			}
+			for _, o := range graph[r] {
+				if !covered[o] {
+					n++
+				}
+			}
+			if n > gain {
+				best, gain = r, n
+			}
+		}
+
+		set = append(set, best)
+		for _, o := range append([]int{best}, graph[best]...) {
+			if !covered[o] {
+				covered[o] = true
+				left--
+			}
+		}
+	}
+
+	return set
+}
+
+// RoomDistanceMatrix returns the grid's rooms together with the shortest-path
+// distances between their centers. Entry [i][j] is the distance from room i to
+// room j, or -1 if j cannot be reached from i.
+//
+// It runs one breadth-first search per room. A single multi-source search
+// only finds each cell's nearest room, not the distance between every pair,
+// so it can't fill the matrix; instead each search works on flat slices
+// reused across rooms and stops once every room center is reached.
+func RoomDistanceMatrix(g *Grid) ([]image.Rectangle, [][]int) {
+	rooms := g.Rooms()
+	matrix := make([][]int, len(rooms))
+	// centerOf[c] is the room whose center is cell c, or -1. Rooms don't
+	// overlap, so no cell is the center of two.
+	centerOf := make([]int, len(g.g))
+	for c := range centerOf {
+		centerOf[c] = -1
+	}
+	for j, r := range rooms {
+		if c := roomCenter(r); g.InBounds(c) {
+			centerOf[c.Y*g.Size.X+c.X] = j
+		}
+	}
+
+	dist := make([]int, len(g.g))
+	queue := make([]int, 0, len(g.g))
+	for i, a := range rooms {
+		matrix[i] = make([]int, len(rooms))
+		for j := range matrix[i] {
+			matrix[i][j] = -1
+		}
+		from := roomCenter(a)
+		if !passable(g.At(from)) {
+			continue
+		}
+
+		for c := range dist {
+			dist[c] = -1
+		}
+		start := from.Y*g.Size.X + from.X
+		dist[start], queue = 0, append(queue[:0], start)
+		left := len(rooms)
+		for head := 0; head < len(queue) && left > 0; head++ {
+			c := queue[head]
+			if j := centerOf[c]; j >= 0 {
+				matrix[i][j] = dist[c]
+				left--
+			}
+			p := Pt(c%g.Size.X, c/g.Size.X)
+			for _, d := range Dirs {
+				n := p.AddDir(d)
+				if !passable(g.At(n)) {
+					continue
+				}
+				if nc := n.Y*g.Size.X + n.X; dist[nc] < 0 {
+					dist[nc] = dist[c] + 1
+					queue = append(queue, nc)
+				}
+			}
+		}
+	}
+
+	return rooms, matrix
+}
+
+// FarthestRooms returns the two rooms whose centers are farthest apart along
+// the maze's passages, and that distance. Rooms that can't reach each other
+// are never paired. With one room, or none connected, it returns a room paired
+// with itself at distance 0; with no rooms, two empty rectangles and -1.
+func FarthestRooms(g *Grid) (a, b image.Rectangle, dist int) {
+	rooms, matrix := RoomDistanceMatrix(g)
+	if len(rooms) == 0 {
+		return a, b, -1
+	}
+	a, b = rooms[0], rooms[0]
+	for i := range rooms {
+		for j := i + 1; j < len(rooms); j++ {
+			if matrix[i][j] > dist {
+				a, b, dist = rooms[i], rooms[j], matrix[i][j]
+			}
+		}
+	}
+	return a, b, dist
+}
+
+// RegionDoors returns the Door cells, in row-major order, with an orthogonal
+// neighbour of region r that is not a door itself: the ways into and out of r.
+// Connecting a generated maze merges all its regions into one, so this only
+// tells rooms apart on grids whose regions still do, such as after
+// SegmentRegions.
+func (g *Grid) RegionDoors(r Region) []Point {
+	doors := make([]Point, 0)
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			p := Pt(x, y)
+			if g.At(p) != Door {
+				continue
+			}
+			for _, d := range Dirs {
+				if n := p.AddDir(d); g.At(n) != Door && passable(g.At(n)) && g.RegionAt(n) == r {
+					doors = append(doors, p)
+					break
+				}
+			}
+		}
+	}
+	return doors
+}
+
+// SegmentRegions relabels g so that every room, every junction and every run
+// of corridor between them and dead ends is a region of its own, numbered
+// from 1 in row-major order of their first cells. Junctions are the passable
+// cells outside rooms with three or more passable neighbours, and each is a
+// region by itself; a door into a room belongs to the corridor it opens onto.
+// Secret passages are left in no region. RenderRegions then shows the
+// maze's segment structure.
+func (g *Grid) SegmentRegions() {
+	index := roomIndex(g)
+	clear(g.regions)
+	g.regCount = NoRegion
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			p := Pt(x, y)
+			if !passable(g.At(p)) || g.RegionAt(p) != NoRegion {
+				continue
+			}
+			region := g.NewRegion()
+			if i := index[y*g.Size.X+x]; i >= 0 {
+				r := g.rooms[i]
+				for ry := r.Min.Y; ry < r.Max.Y; ry++ {
+					for rx := r.Min.X; rx < r.Max.X; rx++ {
+						if passable(g.At(Pt(rx, ry))) {
+							g.SetRegion(Pt(rx, ry), region)
+						}
+					}
+				}
+				continue
+			}
+
+			g.SetRegion(p, region)
+			if isJunction(g, p) {
+				continue
+			}
+			queue := []Point{p}
+			for len(queue) > 0 {
+				c := queue[0]
+				queue = queue[1:]
+				for _, n := range g.passages(c) {
+					if g.RegionAt(n) == NoRegion && index[n.Y*g.Size.X+n.X] < 0 && !isJunction(g, n) {
+						g.SetRegion(n, region)
+						queue = append(queue, n)
+					}
+				}
+			}
+		}
+	}
+}
+
+func roomCenter(r image.Rectangle) Point {
+	return Pt((r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2)
+}
+
+// regionSets is a disjoint-set forest over region ids, so merging regions
+// doesn't mean relabelling their cells.
+type regionSets []Region
+
+func newRegionSets(count Region) regionSets {
+	sets := make(regionSets, count+1)
+	for r := range sets {
+		sets[r] = Region(r)
+	}
+	return sets
+}
+
+// find returns the region standing for r's set, halving the path as it goes.
+func (s regionSets) find(r Region) Region {
+	for s[r] != r {
+		s[r] = s[s[r]]
+		r = s[r]
+	}
+	return r
+}
+
+// union merges b's set into a's; a's representative stays the representative.
+func (s regionSets) union(a, b Region) {
+	s[s.find(b)] = s.find(a)
+}
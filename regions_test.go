@@ -0,0 +1,216 @@
+package maze
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+// roomRow returns a grid of four 3×3 rooms in a row, each joined to the next
+// by a corridor, so that every room but the ends gates the way onwards.
+func roomRow() *Grid {
+	g := parseGrid(
+		"#######################",
+		"#   ###   ###   ###   #",
+		"#                     #",
+		"#   ###   ###   ###   #",
+		"#######################",
+	)
+	for x := 1; x < g.Size.X; x += 6 {
+		g.rooms = append(g.rooms, image.Rect(x, 1, x+3, 4))
+	}
+	return g
+}
+
+func TestCorridorOnlyReachable(t *testing.T) {
+	g := roomRow()
+	for from, want := range [][]int{{1}, {0, 2}, {1, 3}, {2}} {
+		if got := g.CorridorOnlyReachable(from); !reflect.DeepEqual(got, want) {
+			t.Errorf("CorridorOnlyReachable(%d) = %v, want %v", from, got, want)
+		}
+	}
+}
+
+func TestDominatingRooms(t *testing.T) {
+	g := roomRow()
+	set := DominatingRooms(g)
+	if want := []int{1, 2}; !reflect.DeepEqual(set, want) {
+		t.Errorf("DominatingRooms = %v, want %v", set, want)
+	}
+
+	g, _, _ = mustGenerate(t, testConfig(Pt(61, 61), 11))
+	covered := make(map[int]bool)
+	for _, r := range DominatingRooms(g) {
+		covered[r] = true
+		for _, o := range g.CorridorOnlyReachable(r) {
+			covered[o] = true
+		}
+	}
+	if len(covered) != len(g.Rooms()) {
+		t.Errorf("DominatingRooms covers %d of %d rooms", len(covered), len(g.Rooms()))
+	}
+}
+
+// threeRooms returns a grid of three 3×3 rooms in a row, six cells apart and
+// joined by corridors.
+func threeRooms() *Grid {
+	g := parseGrid(
+		"#################",
+		"#   ###   ###   #",
+		"#               #",
+		"#   ###   ###   #",
+		"#################",
+	)
+	for x := 1; x < g.Size.X; x += 6 {
+		g.rooms = append(g.rooms, image.Rect(x, 1, x+3, 4))
+	}
+	return g
+}
+
+func TestRoomDistanceMatrix(t *testing.T) {
+	g := threeRooms()
+	rooms, matrix := RoomDistanceMatrix(g)
+	if !reflect.DeepEqual(rooms, g.rooms) {
+		t.Errorf("RoomDistanceMatrix rooms = %v, want %v", rooms, g.rooms)
+	}
+	if want := [][]int{{0, 6, 12}, {6, 0, 6}, {12, 6, 0}}; !reflect.DeepEqual(matrix, want) {
+		t.Errorf("RoomDistanceMatrix = %v, want %v", matrix, want)
+	}
+
+	g.SetMaterial(Pt(12, 2), Rock)
+	_, matrix = RoomDistanceMatrix(g)
+	if want := [][]int{{0, 6, -1}, {6, 0, -1}, {-1, -1, 0}}; !reflect.DeepEqual(matrix, want) {
+		t.Errorf("RoomDistanceMatrix with a room cut off = %v, want %v", matrix, want)
+	}
+}
+
+// BenchmarkRoomDistanceMatrix measures the one-search-per-room approach on a
+// large maze with many rooms.
+func BenchmarkRoomDistanceMatrix(b *testing.B) {
+	cfg := testConfig(Pt(241, 241), 19)
+	cfg.Rooms.Tries = 400
+	g, _, _ := mustGenerate(b, cfg)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		RoomDistanceMatrix(g)
+	}
+	b.ReportMetric(float64(len(g.Rooms())), "rooms")
+}
+
+func TestFarthestRooms(t *testing.T) {
+	g := threeRooms()
+	a, b, dist := FarthestRooms(g)
+	if a != g.rooms[0] || b != g.rooms[2] || dist != 12 {
+		t.Errorf("FarthestRooms = %v, %v, %d; want %v, %v, 12", a, b, dist, g.rooms[0], g.rooms[2])
+	}
+
+	// Wall off the last room: the first two are now the farthest pair.
+	g.SetMaterial(Pt(12, 2), Rock)
+	a, b, dist = FarthestRooms(g)
+	if a != g.rooms[0] || b != g.rooms[1] || dist != 6 {
+		t.Errorf("FarthestRooms with a room cut off = %v, %v, %d; want %v, %v, 6", a, b, dist, g.rooms[0], g.rooms[1])
+	}
+
+	g.rooms = g.rooms[:1]
+	if a, b, dist = FarthestRooms(g); a != g.rooms[0] || b != g.rooms[0] || dist != 0 {
+		t.Errorf("FarthestRooms with one room = %v, %v, %d; want it paired with itself", a, b, dist)
+	}
+	g.rooms = nil
+	if _, _, dist = FarthestRooms(g); dist != -1 {
+		t.Errorf("FarthestRooms with no rooms = %d, want -1", dist)
+	}
+}
+
+func TestRegionDoors(t *testing.T) {
+	g := parseGrid(
+		"###########",
+		"#   #   # #",
+		"#   +   + #",
+		"#   #   # #",
+		"###+#######",
+		"#   #######",
+		"###########",
+	)
+	// The left room keeps region 1; the middle room, the corridor right of it
+	// and the one below the left room get regions of their own.
+	room, right, below := g.NewRegion(), g.NewRegion(), g.NewRegion()
+	for y := 1; y < 4; y++ {
+		for x := 5; x < 8; x++ {
+			g.SetRegion(Pt(x, y), room)
+		}
+		g.SetRegion(Pt(9, y), right)
+	}
+	for x := 1; x < 4; x++ {
+		g.SetRegion(Pt(x, 5), below)
+	}
+
+	for r, want := range map[Region][]Point{
+		1:         {Pt(4, 2), Pt(3, 4)},
+		room:      {Pt(4, 2), Pt(8, 2)},
+		right:     {Pt(8, 2)},
+		below:     {Pt(3, 4)},
+		below + 1: {},
+	} {
+		if got := g.RegionDoors(r); !reflect.DeepEqual(got, want) {
+			t.Errorf("RegionDoors(%d) = %v, want %v", r, got, want)
+		}
+	}
+}
+
+func TestSegmentRegions(t *testing.T) {
+	g := parseGrid(
+		"#########",
+		"#   #####",
+		"#   +   #",
+		"#   ### #",
+		"##+## # #",
+		"#     # #",
+		"#########",
+	)
+	g.rooms, g.roomTypes = []image.Rectangle{image.Rect(1, 1, 4, 4)}, []string{""}
+	g.SegmentRegions()
+	if err := g.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The room; the corridor from its right door to the dead end below; the
+	// door below it; the junction under that door; the dead end left of the
+	// junction; and the corridor right of it, with the stub above its end.
+	segments := [][]Point{
+		{Pt(1, 1), Pt(3, 3)},
+		{Pt(4, 2), Pt(7, 2), Pt(7, 5)},
+		{Pt(2, 4)},
+		{Pt(2, 5)},
+		{Pt(1, 5)},
+		{Pt(3, 5), Pt(5, 5), Pt(5, 4)},
+	}
+	if got := len(g.Regions()); got != len(segments) {
+		t.Errorf("%d regions, want %d segments", got, len(segments))
+	}
+	seen := make(map[Region]int)
+	for i, cells := range segments {
+		r := g.RegionAt(cells[0])
+		if j, ok := seen[r]; ok {
+			t.Errorf("segments %d and %d share region %d", j, i, r)
+		}
+		seen[r] = i
+		for _, p := range cells[1:] {
+			if g.RegionAt(p) != r {
+				t.Errorf("%v is in region %d, want %d with %v", p, g.RegionAt(p), r, cells[0])
+			}
+		}
+	}
+	if got, want := g.RegionDoors(g.RegionAt(Pt(1, 1))), []Point{Pt(4, 2), Pt(2, 4)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("room doors %v, want %v", got, want)
+	}
+
+	cfg := testConfig(Pt(41, 31), 6)
+	cfg.SegmentRegions = true
+	maze, _, _ := mustGenerate(t, cfg)
+	if err := maze.Check(); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(maze.Regions()); got <= len(maze.Rooms()) {
+		t.Errorf("%d regions for %d rooms, want the corridors split too", got, len(maze.Rooms()))
+	}
+}
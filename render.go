@@ -0,0 +1,136 @@
+package maze
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+)
+
+// Cell size, in pixels, of SVG output unless RenderOptions.Scale is set.
+const SVG_CELL_SIZE = 10
+
+// RenderOptions adjust how the image renderers draw a grid.
+type RenderOptions struct {
+	// Scale is the side, in pixels, of every cell; 0 means 1, or
+	// SVG_CELL_SIZE for SVG and ISO_CELL_SIZE for RenderIso.
+	Scale int
+	// Theme colors the image; nil means DefaultTheme.
+	Theme *Theme
+	// RevealSecrets draws secret passages in the theme's Secret color
+	// instead of disguising them as rock.
+	RevealSecrets bool
+	// BoxDrawing has RenderText draw walls with joined box-drawing lines
+	// instead of '#'.
+	BoxDrawing bool
+	// RoundCorners rounds off the corners of rock cells that stick out into
+	// the passages, as in RenderMaterials and RenderRegions. It needs a
+	// Scale of at least 4; corners stay square at smaller scales.
+	RoundCorners bool
+	// RulerEvery, if set, has RenderAnnotated add tick marks and cell
+	// coordinates every RulerEvery cells in a margin along its top and left.
+	RulerEvery int
+}
+
+func (o RenderOptions) scale(def int) int {
+	if o.Scale > 0 {
+		return o.Scale
+	}
+	return def
+}
+
+func (o RenderOptions) theme() Theme {
+	if o.Theme != nil {
+		return *o.Theme
+	}
+	return DefaultTheme()
+}
+
+// materialsRGBA draws every cell of g, opts.Scale pixels square, in its
+// material's theme color on a full-color image that overlays can be drawn on
+// without being held to the theme's palette.
+func materialsRGBA(g *Grid, opts RenderOptions) *image.RGBA {
+	scale, theme := opts.scale(1), opts.theme()
+	img := image.NewRGBA(scaled(g.Bounds(), scale))
+	fill := func(p Point) color.Color { return theme.material(g.At(p), opts.RevealSecrets) }
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			fillCell(img, Pt(x, y), scale, fill(Pt(x, y)))
+		}
+	}
+	if opts.RoundCorners {
+		roundCorners(img, g, scale, fill)
+	}
+	return img
+}
+
+// roundCorners cuts a quarter circle of radius scale/2 off every corner of a
+// rock cell whose two neighbours beside that corner are passable, painting
+// the pixels cut off in fill of the neighbour above or below. Below a scale of
+// 4 there is too little room for a curve, and corners are left square.
+func roundCorners(img draw.Image, g *Grid, scale int, fill func(Point) color.Color) {
+	if scale < 4 {
+		return
+	}
+	r := float64(scale) / 2
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			p := Pt(x, y)
+			if g.At(p) != Rock {
+				continue
+			}
+			for _, c := range []Point{Pt(-1, -1), Pt(1, -1), Pt(-1, 1), Pt(1, 1)} {
+				beside := p.Add(Pt(c.X, 0))
+				above := p.Add(Pt(0, c.Y))
+				if !passable(g.At(beside)) || !passable(g.At(above)) {
+					continue
+				}
+				col := fill(above)
+				// (cx, cy) is the corner's center of curvature, in pixels.
+				cx, cy := float64(x*scale)+r, float64(y*scale)+r
+				for j := 0; j < scale/2; j++ {
+					for i := 0; i < scale/2; i++ {
+						px, py := x*scale+i, y*scale+j
+						if c.X > 0 {
+							px = (x+1)*scale - 1 - i
+						}
+						if c.Y > 0 {
+							py = (y+1)*scale - 1 - j
+						}
+						if math.Hypot(float64(px)+0.5-cx, float64(py)+0.5-cy) > r {
+							img.Set(px, py, col)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// Render writes the grid to w in the given format, named like a file
+// extension: "png" for the annotated image, "svg", "txt" or "text", "html",
+// "json", "rle" and "maze" for the binary format. opts applies to the image
+// and text formats.
+func (g *Grid) Render(w io.Writer, format string, opts RenderOptions) error {
+	switch format {
+	case "png":
+		return png.Encode(w, g.RenderAnnotated(nil, opts))
+	case "svg":
+		return g.RenderSVG(w, opts)
+	case "txt", "text":
+		return g.RenderText(w, opts)
+	case "html":
+		return g.WriteHTML(w)
+	case "json":
+		return json.NewEncoder(w).Encode(g)
+	case "rle":
+		return g.EncodeRLE(w)
+	case "maze":
+		return g.WriteBinary(w)
+	}
+	return fmt.Errorf("maze: unknown format %q", format)
+}
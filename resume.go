@@ -0,0 +1,166 @@
+package maze
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"math/rand"
+)
+
+// maxDrawsPerCell bounds the draws a state may claim, per grid cell and room
+// try; generation takes about one per cell, so a state beyond it is corrupt
+// and would take a long time to skip to.
+const maxDrawsPerCell = 64
+
+// countingSource is a rand.Source that counts its draws, so that a generation
+// can be resumed from the same point in the sequence.
+type countingSource struct {
+	src   rand.Source
+	draws int64
+}
+
+// newCountingSource returns the source seeded with seed, after skipping its
+// first draws values.
+func newCountingSource(seed, draws int64) *countingSource {
+	src := rand.NewSource(seed)
+	for i := int64(0); i < draws; i++ {
+		src.Int63()
+	}
+	return &countingSource{src, draws}
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.draws = 0
+}
+
+// GenerationState is a generation stopped while growing corridors: the grid
+// carved so far and where in the seed's random sequence, the lattice and the
+// current corridor it stopped.
+type GenerationState struct {
+	grid     *Grid
+	seed     int64
+	draws    int64
+	corridor corridorState
+}
+
+// Interrupted is the error Generate returns when its context is done while it
+// grows corridors. Err is the context's error.
+type Interrupted struct {
+	Err   error
+	State *GenerationState
+}
+
+func (e *Interrupted) Error() string {
+	return "maze: generation interrupted: " + e.Err.Error()
+}
+
+func (e *Interrupted) Unwrap() error {
+	return e.Err
+}
+
+// stateJSON is the JSON form of a GenerationState. Headings holds the
+// direction each of Cells was carved from, or 0,0 for the first.
+type stateJSON struct {
+	Grid     json.RawMessage
+	Seed     int64
+	Draws    int64
+	Next     Point
+	Growing  bool
+	Region   Region
+	Cells    []Point
+	Headings []Point
+}
+
+// SaveState writes st as JSON.
+func SaveState(w io.Writer, st *GenerationState) error {
+	grid, err := st.grid.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	j := stateJSON{Grid: grid, Seed: st.seed, Draws: st.draws, Next: st.corridor.next}
+	if gr := st.corridor.growing; gr != nil {
+		j.Growing, j.Region, j.Cells = true, gr.region, gr.cells
+		for _, c := range gr.cells {
+			h := Pt(0, 0)
+			if d, ok := gr.heading[c]; ok {
+				h = *d.Point
+			}
+			j.Headings = append(j.Headings, h)
+		}
+	}
+	return json.NewEncoder(w).Encode(j)
+}
+
+// ResumeState reads a state written by SaveState and finishes the generation
+// it was taken from, which must have used cfg. The maze is the one Generate
+// would have made from cfg uninterrupted; onStep and ctx work as in Generate,
+// and ResumeState too can be interrupted.
+func ResumeState(ctx context.Context, r io.Reader, cfg Config, onStep func(*Grid)) (grid *Grid, entrance, exit Point, err error) {
+	var j stateJSON
+	if err := json.NewDecoder(r).Decode(&j); err != nil {
+		return nil, entrance, exit, fmt.Errorf("maze: state: %s", err)
+	}
+	if grid, err = LoadGrid(bytes.NewReader(j.Grid)); err != nil {
+		return nil, entrance, exit, err
+	}
+	switch {
+	case j.Seed != cfg.Seed:
+		return nil, entrance, exit, fmt.Errorf("maze: state: seed %d, config wants %d", j.Seed, cfg.Seed)
+	case grid.Size != cfg.Size:
+		return nil, entrance, exit, fmt.Errorf("maze: state: grid is %dx%d, config wants %dx%d", grid.Size.X, grid.Size.Y, cfg.Size.X, cfg.Size.Y)
+	case j.Draws < 0 || j.Draws > maxDrawsPerCell*int64(len(grid.g)+max(cfg.Rooms.Tries, 0)):
+		return nil, entrance, exit, fmt.Errorf("maze: state: draw count %d out of range", j.Draws)
+	case !j.Next.In(image.Rect(1, 1, grid.Size.X+1, grid.Size.Y+1)):
+		return nil, entrance, exit, fmt.Errorf("maze: state: next cell %v outside %dx%d grid", j.Next, grid.Size.X, grid.Size.Y)
+	case len(j.Headings) != len(j.Cells):
+		return nil, entrance, exit, fmt.Errorf("maze: state: %d headings for %d cells", len(j.Headings), len(j.Cells))
+	case j.Growing && (j.Region <= NoRegion || j.Region > grid.regCount):
+		return nil, entrance, exit, fmt.Errorf("maze: state: region %d, want 1..%d", j.Region, grid.regCount)
+	}
+	for i, c := range j.Cells {
+		if !grid.InBounds(c) {
+			return nil, entrance, exit, fmt.Errorf("maze: state: cell %v outside %dx%d grid", c, grid.Size.X, grid.Size.Y)
+		}
+		if h := j.Headings[i]; h != Pt(0, 0) && !isDir(h) {
+			return nil, entrance, exit, fmt.Errorf("maze: state: heading %v at cell %v is not a direction", h, c)
+		}
+	}
+
+	st := corridorState{next: j.Next}
+	if j.Growing {
+		gr := &growth{j.Region, j.Cells, make(map[Point]direction)}
+		for i, h := range j.Headings {
+			if h != Pt(0, 0) {
+				gr.heading[j.Cells[i]] = D(h.X, h.Y)
+			}
+		}
+		st.growing = gr
+	}
+	grid.mask = cfg.mask()
+
+	src := newCountingSource(cfg.Seed, j.Draws)
+	entrance, exit, err = finishMaze(ctx, grid, cfg, src, rand.New(src), &st, onStep)
+	if err != nil {
+		return nil, entrance, exit, err
+	}
+	return grid, entrance, exit, nil
+}
+
+// isDir reports whether p is one of the offsets in Dirs.
+func isDir(p Point) bool {
+	for _, d := range Dirs {
+		if *d.Point == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package maze
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// interruptAfter generates cfg, cancelling it after steps steps, and returns
+// the state it stopped in, saved.
+func interruptAfter(t *testing.T, steps int, cfg Config) *bytes.Buffer {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	_, _, _, err := Generate(ctx, cfg, func(*Grid) {
+		if n++; n == steps {
+			cancel()
+		}
+	})
+	var stopped *Interrupted
+	if !errors.As(err, &stopped) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Generate cancelled after %d steps returned %v, want an *Interrupted", steps, err)
+	}
+	var buf bytes.Buffer
+	if err := SaveState(&buf, stopped.State); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func sameMaze(a, b *Grid) bool {
+	return reflect.DeepEqual(a.g, b.g) && reflect.DeepEqual(a.regions, b.regions) &&
+		a.regCount == b.regCount && reflect.DeepEqual(a.rooms, b.rooms)
+}
+
+func TestResumeState(t *testing.T) {
+	loose := testConfig(Pt(41, 41), 17)
+	loose.WindingPercent, loose.MaxCorridorRun, loose.ExtraConnectors, loose.SecretChance = 40, 10, 5, 0.5
+	loose.Density = func(p Point) float64 { return 0.5 + float64(p.X)/82 }
+	backtracker := testConfig(Pt(31, 41), 18)
+	backtracker.Algorithm = Backtracker
+
+	for _, cfg := range []Config{loose, backtracker} {
+		want, wantIn, wantOut := mustGenerate(t, cfg)
+		for _, steps := range []int{len(want.rooms) + 1, 50, 200} {
+			state := interruptAfter(t, steps, cfg)
+			g, in, out, err := ResumeState(context.Background(), state, cfg, nil)
+			if err != nil {
+				t.Fatalf("ResumeState after %d steps: %s", steps, err)
+			}
+			if !sameMaze(g, want) || in != wantIn || out != wantOut {
+				t.Errorf("maze resumed after %d steps differs from one generated uninterrupted", steps)
+			}
+		}
+
+		// Interrupt the resumed generation too, then resume that.
+		ctx, cancel := context.WithCancel(context.Background())
+		n := 0
+		_, _, _, err := ResumeState(ctx, interruptAfter(t, 50, cfg), cfg, func(*Grid) {
+			if n++; n == 100 {
+				cancel()
+			}
+		})
+		cancel()
+		var stopped *Interrupted
+		if !errors.As(err, &stopped) {
+			t.Fatalf("ResumeState cancelled returned %v, want an *Interrupted", err)
+		}
+		var buf bytes.Buffer
+		if err := SaveState(&buf, stopped.State); err != nil {
+			t.Fatal(err)
+		}
+		g, _, _, err := ResumeState(context.Background(), &buf, cfg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sameMaze(g, want) {
+			t.Error("maze resumed twice differs from one generated uninterrupted")
+		}
+	}
+
+	other := loose
+	other.Seed++
+	if _, _, _, err := ResumeState(context.Background(), interruptAfter(t, 50, loose), other, nil); err == nil {
+		t.Error("ResumeState accepted a config with another seed")
+	}
+}
+
+func TestResumeStateMalformed(t *testing.T) {
+	cfg := testConfig(Pt(21, 21), 19)
+	var valid stateJSON
+	g, _, _ := mustGenerate(t, cfg)
+	state := interruptAfter(t, len(g.rooms)+5, cfg)
+	if err := json.NewDecoder(state).Decode(&valid); err != nil {
+		t.Fatal(err)
+	}
+	if !valid.Growing || len(valid.Cells) == 0 {
+		t.Fatal("state was not saved while growing a corridor")
+	}
+
+	for _, c := range []struct {
+		name    string
+		corrupt func(j *stateJSON)
+	}{
+		{"next outside", func(j *stateJSON) { j.Next = Pt(-5, 1) }},
+		{"next below", func(j *stateJSON) { j.Next = Pt(1, 40) }},
+		{"cell outside", func(j *stateJSON) { j.Cells[0] = Pt(21, 3) }},
+		{"bad heading", func(j *stateJSON) { j.Headings[0] = Pt(3, 0) }},
+		{"no region", func(j *stateJSON) { j.Region = NoRegion }},
+		{"unknown region", func(j *stateJSON) { j.Region = 1 << 20 }},
+		{"negative draws", func(j *stateJSON) { j.Draws = -1 }},
+		{"huge draws", func(j *stateJSON) { j.Draws = 1 << 62 }},
+	} {
+		j := valid
+		j.Cells = append([]Point(nil), valid.Cells...)
+		j.Headings = append([]Point(nil), valid.Headings...)
+		c.corrupt(&j)
+		data, err := json.Marshal(j)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, _, err := ResumeState(context.Background(), bytes.NewReader(data), cfg, nil); err == nil {
+			t.Errorf("%s: ResumeState succeeded", c.name)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package maze
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+)
+
+const (
+	glyphW  = 3
+	glyphH  = 5
+	tickLen = 2
+)
+
+// 3x5 bitmap digits, row by row.
+var glyphs = [10]string{
+	"111101101101111",
+	"010110010010111",
+	"111001111100111",
+	"111001111001111",
+	"101101111001001",
+	"111100111001111",
+	"111100111101111",
+	"111001001001001",
+	"111101111101111",
+	"111101111001111",
+}
+
+// withRuler returns a copy of img, drawn scale pixels per cell, with a margin
+// along the top and left edges holding tick marks and cell coordinates every
+// `every` cells.
+func withRuler(img *image.Paletted, every, scale int) *image.Paletted {
+	b := img.Bounds()
+	digits := len(strconv.Itoa(max(b.Dx(), b.Dy())/scale - 1))
+	left := digits*(glyphW+1) + tickLen + 1
+	top := glyphH + tickLen + 2
+
+	out := image.NewPaletted(image.Rect(0, 0, b.Dx()+left, b.Dy()+top), img.Palette)
+	draw.Draw(out, out.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(out, image.Rect(left, top, left+b.Dx(), top+b.Dy()), img, b.Min, draw.Src)
+
+	labelEnd := -1
+	for x := 0; x < b.Dx()/scale; x += every {
+		px := x*scale + scale/2
+		for i := 1; i <= tickLen; i++ {
+			out.Set(left+px, top-i, color.Black)
+		}
+		label := strconv.Itoa(x)
+		lx := left + px - (len(label)*(glyphW+1)-1)/2
+		if lx > labelEnd {
+			drawLabel(out, label, lx, 0)
+			labelEnd = lx + len(label)*(glyphW+1)
+		}
+	}
+
+	labelEnd = -1
+	for y := 0; y < b.Dy()/scale; y += every {
+		py := y*scale + scale/2
+		for i := 1; i <= tickLen; i++ {
+			out.Set(left-i, top+py, color.Black)
+		}
+		label := strconv.Itoa(y)
+		ly := max(top+py-glyphH/2, 0)
+		if ly > labelEnd {
+			drawLabel(out, label, left-tickLen-1-len(label)*(glyphW+1), ly)
+			labelEnd = ly + glyphH
+		}
+	}
+
+	return out
+}
+
+func drawLabel(img *image.Paletted, label string, x, y int) {
+	for i, r := range label {
+		glyph := glyphs[r-'0']
+		for p, bit := range glyph {
+			if bit == '1' {
+				img.Set(x+i*(glyphW+1)+p%glyphW, y+p/glyphW, color.Black)
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package maze
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestRuler(t *testing.T) {
+	g, _, _ := mustGenerate(t, testConfig(Pt(21, 15), 8))
+	const scale = 4
+	plain := g.RenderAnnotated(nil, RenderOptions{Scale: scale})
+	ruled := g.RenderAnnotated(nil, RenderOptions{Scale: scale, RulerEvery: 5})
+
+	pb, rb := plain.Bounds(), ruled.Bounds()
+	left, top := rb.Dx()-pb.Dx(), rb.Dy()-pb.Dy()
+	if left <= 0 || top <= 0 {
+		t.Fatalf("ruled image is %v, plain %v; want a margin on the top and left", rb, pb)
+	}
+	for y := 0; y < pb.Dy(); y++ {
+		for x := 0; x < pb.Dx(); x++ {
+			if a, b := plain.At(x, y), ruled.At(left+x, top+y); !sameColor(a, b) {
+				t.Fatalf("pixel (%d, %d) moved into the margin changed from %v to %v", x, y, a, b)
+			}
+		}
+	}
+
+	// A tick sits above the center of every fifth column.
+	for x := 0; x < g.Size.X; x += 5 {
+		if c := ruled.At(left+x*scale+scale/2, top-1); !sameColor(c, color.Black) {
+			t.Errorf("no tick above column %d", x)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package maze
+
+import "math/rand"
+
+// placeSecrets looks for pairs of dead ends facing each other across a single
+// rock cell and, with probability prob per pair, turns that cell into a Secret
+// passage. It returns the cells it converted.
+func placeSecrets(g *Grid, prob float64, rng *rand.Rand) []Point {
+	bounds := g.Bounds()
+	secrets := make([]Point, 0)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			here := Pt(x, y)
+			if !isDeadEnd(g, here) {
+				continue
+			}
+			for _, d := range []direction{Dir.Right, Dir.Down} {
+				wall := here.AddDir(d)
+				there := wall.AddDir(d)
+				if !there.In(bounds) || g.At(wall) != Rock || !isDeadEnd(g, there) {
+					continue
+				}
+				if rng.Float64() < prob {
+					g.SetMaterial(wall, Secret)
+					secrets = append(secrets, wall)
+				}
+			}
+		}
+	}
+
+	return secrets
+}
+
+func isDeadEnd(g *Grid, p Point) bool {
+	return passable(g.At(p)) && len(g.passages(p)) == 1
+}
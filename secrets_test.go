@@ -0,0 +1,59 @@
+package maze
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPlaceSecrets(t *testing.T) {
+	g := parseGrid(
+		"#######",
+		"#  #  #",
+		"#######",
+	)
+	labels, _ := g.Components(Moves{})
+	if labels[Pt(2, 1)] == labels[Pt(4, 1)] {
+		t.Fatal("dead ends already connected")
+	}
+
+	secrets := placeSecrets(g, 1, rand.New(rand.NewSource(1)))
+	if len(secrets) != 1 || secrets[0] != Pt(3, 1) {
+		t.Fatalf("placeSecrets = %v, want [%v]", secrets, Pt(3, 1))
+	}
+	if g.At(Pt(3, 1)) != Secret {
+		t.Errorf("At(%v) = %d, want Secret", Pt(3, 1), g.At(Pt(3, 1)))
+	}
+
+	if got := placeSecrets(parseGrid("#######", "#  #  #", "#######"), 0, rand.New(rand.NewSource(1))); len(got) != 0 {
+		t.Errorf("placeSecrets with chance 0 = %v, want none", got)
+	}
+}
+
+func TestGenerateSecrets(t *testing.T) {
+	cfg := testConfig(Pt(61, 61), 6)
+	cfg.SecretChance = 1
+	g, _, _ := mustGenerate(t, cfg)
+
+	found := 0
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			p := Pt(x, y)
+			if g.At(p) != Secret {
+				continue
+			}
+			found++
+			open := 0
+			for _, n := range g.passages(p) {
+				if isDeadEnd(g, n) {
+					open++
+				}
+			}
+			if open != 2 {
+				t.Errorf("secret %v joins %d dead ends, want 2", p, open)
+			}
+		}
+	}
+	if found == 0 {
+		t.Error("no secrets placed with chance 1")
+	}
+}
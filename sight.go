@@ -0,0 +1,79 @@
+package maze
+
+import (
+	"image"
+	"image/png"
+	"io"
+)
+
+// lineClear walks the Bresenham line from a to b, moving one orthogonal step
+// at a time, and reports whether every cell strictly between them is
+// passable.
+func (g *Grid) lineClear(a, b Point) bool {
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+
+	err := dx + dy
+	p := a
+	for {
+		if p != a && p != b && !passable(g.At(p)) {
+			return false
+		}
+		if p == b {
+			return true
+		}
+		if e2 := 2 * err; e2 >= dy {
+			err += dy
+			p.X += sx
+		} else {
+			err += dx
+			p.Y += sy
+		}
+	}
+}
+
+// LineOfSight reports whether the straight line between the centers of a and b
+// crosses only passable cells, endpoints included. The line is traced one
+// orthogonal step at a time, so it cannot slip diagonally between two rock
+// cells that touch at a corner. A cell can always see itself and its
+// passable neighbours, provided it is passable.
+func (g *Grid) LineOfSight(a, b Point) bool {
+	if !passable(g.At(a)) || !passable(g.At(b)) {
+		return false
+	}
+	return g.lineClear(a, b)
+}
+
+// RenderVisible renders what can be seen from `from`, opts.Scale pixels square
+// per cell: cells within radius whose line of sight from `from` is not blocked
+// by rock. Walls bounding the view are shown; everything else is drawn in the
+// theme's fog color.
+func (g *Grid) RenderVisible(w io.Writer, from Point, radius int, opts RenderOptions) error {
+	scale, theme := opts.scale(1), opts.theme()
+	img := image.NewPaletted(scaled(g.Bounds(), scale), theme.palette())
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			p := Pt(x, y)
+			dx, dy := x-from.X, y-from.Y
+			if dx*dx+dy*dy <= radius*radius && g.lineClear(from, p) {
+				fillCell(img, p, scale, theme.material(g.At(p), opts.RevealSecrets))
+			} else {
+				fillCell(img, p, scale, theme.Fog)
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
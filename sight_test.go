@@ -0,0 +1,69 @@
+package maze
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestRenderVisible(t *testing.T) {
+	g := parseGrid(
+		"#######",
+		"#  #  #",
+		"#######",
+	)
+	var buf bytes.Buffer
+	if err := g.RenderVisible(&buf, Pt(1, 1), 10, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	theme := DefaultTheme()
+	for _, c := range []struct {
+		p    Point
+		want color.Color
+		name string
+	}{
+		{Pt(2, 1), theme.Carved, "carved"},
+		{Pt(3, 1), theme.Rock, "rock"},
+		{Pt(4, 1), theme.Fog, "fog"},
+		{Pt(5, 1), theme.Fog, "fog"},
+	} {
+		if got := img.At(c.p.X, c.p.Y); !sameColor(got, c.want) {
+			t.Errorf("cell %v drawn %v, want %s %v", c.p, got, c.name, c.want)
+		}
+	}
+}
+
+func TestLineOfSight(t *testing.T) {
+	g := parseGrid(
+		"#######",
+		"#     #",
+		"#  #  #",
+		"#     #",
+		"#######",
+	)
+	for _, c := range []struct {
+		a, b Point
+		want bool
+	}{
+		{Pt(1, 1), Pt(5, 1), true},
+		{Pt(1, 1), Pt(2, 3), true},
+		{Pt(1, 1), Pt(5, 3), false},
+		{Pt(1, 2), Pt(5, 2), false},
+		{Pt(2, 1), Pt(4, 3), false},
+		{Pt(1, 1), Pt(1, 1), true},
+		{Pt(1, 1), Pt(3, 2), false},
+	} {
+		if got := g.LineOfSight(c.a, c.b); got != c.want {
+			t.Errorf("LineOfSight(%v, %v) = %t, want %t", c.a, c.b, got, c.want)
+		}
+		if got := g.LineOfSight(c.b, c.a); got != c.want {
+			t.Errorf("LineOfSight(%v, %v) = %t, want %t", c.b, c.a, got, c.want)
+		}
+	}
+}
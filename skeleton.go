@@ -0,0 +1,88 @@
+package maze
+
+import (
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"io"
+)
+
+// Skeleton thins the carved cells down to their medial axis with the
+// Zhang-Suen algorithm, keeping connectivity. One-cell-wide corridors are
+// their own skeleton; wider areas shrink to a thin spine. The cells are
+// returned in row-major order.
+func Skeleton(g *Grid) []Point {
+	on := make([]bool, len(g.g))
+	for i, m := range g.g {
+		on[i] = passable(m)
+	}
+	at := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < g.Size.X && y < g.Size.Y && on[y*g.Size.X+x]
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for step := 0; step < 2; step++ {
+			strip := make([]int, 0)
+			for y := 0; y < g.Size.Y; y++ {
+				for x := 0; x < g.Size.X; x++ {
+					if !at(x, y) {
+						continue
+					}
+					// P2..P9, clockwise from north.
+					n := [8]bool{
+						at(x, y-1), at(x+1, y-1), at(x+1, y), at(x+1, y+1),
+						at(x, y+1), at(x-1, y+1), at(x-1, y), at(x-1, y-1),
+					}
+					count, transitions := 0, 0
+					for i := range n {
+						if n[i] {
+							count++
+						}
+						if !n[i] && n[(i+1)%8] {
+							transitions++
+						}
+					}
+					if count < 2 || count > 6 || transitions != 1 {
+						continue
+					}
+					p2, p4, p6, p8 := n[0], n[2], n[4], n[6]
+					if step == 0 && (p2 && p4 && p6 || p4 && p6 && p8) {
+						continue
+					}
+					if step == 1 && (p2 && p4 && p8 || p2 && p6 && p8) {
+						continue
+					}
+					strip = append(strip, y*g.Size.X+x)
+				}
+			}
+			for _, i := range strip {
+				on[i] = false
+			}
+			changed = changed || len(strip) > 0
+		}
+	}
+
+	skel := make([]Point, 0)
+	for i, v := range on {
+		if v {
+			skel = append(skel, Pt(i%g.Size.X, i/g.Size.X))
+		}
+	}
+	return skel
+}
+
+// RenderSkeleton writes a PNG of the grid in its material colors, opts.Scale
+// pixels square per cell, with the cells of its Skeleton drawn over it.
+func (g *Grid) RenderSkeleton(w io.Writer, opts RenderOptions) error {
+	scale := opts.scale(1)
+	img := materialsRGBA(g, opts)
+	renderSkeleton(img, Skeleton(g), scale)
+	return png.Encode(w, img)
+}
+
+func renderSkeleton(img draw.Image, skel []Point, scale int) {
+	for _, p := range skel {
+		fillCell(img, p, scale, palette.Plan9[60])
+	}
+}
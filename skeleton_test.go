@@ -0,0 +1,44 @@
+package maze
+
+import (
+	"bytes"
+	"image/color/palette"
+	"image/png"
+	"testing"
+)
+
+func TestSkeletonCorridor(t *testing.T) {
+	g := parseGrid(
+		"############",
+		"#          #",
+		"#          #",
+		"############",
+	)
+	skel := Skeleton(g)
+	if len(skel) < 6 {
+		t.Fatalf("skeleton %v is too short to follow a 10-cell corridor", skel)
+	}
+	for i, p := range skel {
+		if p.Y != skel[0].Y || p.X != skel[0].X+i {
+			t.Fatalf("skeleton %v is not one straight line along the corridor", skel)
+		}
+	}
+	if y := skel[0].Y; y != 1 && y != 2 {
+		t.Errorf("skeleton %v is outside the corridor", skel)
+	}
+
+	var buf bytes.Buffer
+	if err := g.RenderSkeleton(&buf, RenderOptions{Scale: 2}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := palette.Plan9[60]
+	for _, p := range skel {
+		if img.At(p.X*2+1, p.Y*2+1) != want {
+			t.Errorf("skeleton cell %v drawn %v, want %v", p, img.At(p.X*2+1, p.Y*2+1), want)
+		}
+	}
+}
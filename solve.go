@@ -0,0 +1,58 @@
+package maze
+
+// Solve finds a shortest path from start to goal through passable cells by
+// breadth-first search. The path includes both ends; ok is false if either
+// end is out of bounds or on rock, or if goal cannot be reached.
+func (g *Grid) Solve(start, goal Point) (path []Point, ok bool) {
+	return g.SolveWith(start, goal, Moves{})
+}
+
+// SolveWith is Solve, stepping under m: diagonally as well if m allows it, and
+// through secret passages if m.AllowSecrets is set.
+func (g *Grid) SolveWith(start, goal Point, m Moves) (path []Point, ok bool) {
+	if !Passable(g.At(start), m.AllowSecrets) || !Passable(g.At(goal), m.AllowSecrets) {
+		return nil, false
+	}
+	return shortestPath(start, goal, func(p Point) []Point {
+		return g.moves(p, m)
+	}, nil)
+}
+
+// shortestPath finds a shortest path from start to goal by breadth-first
+// search, stepping from each cell to the cells next returns for it. If visit
+// is not nil it is called with every cell as the search reaches it, in order,
+// goal last if it is found.
+func shortestPath[T comparable](start, goal T, next func(T) []T, visit func(T)) (path []T, ok bool) {
+	parent := map[T]T{start: start}
+	queue := []T{start}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if visit != nil {
+			visit(p)
+		}
+		if p == goal {
+			break
+		}
+		for _, n := range next(p) {
+			if _, seen := parent[n]; !seen {
+				parent[n] = p
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	if _, found := parent[goal]; !found {
+		return nil, false
+	}
+
+	for p := goal; p != start; p = parent[p] {
+		path = append(path, p)
+	}
+	path = append(path, start)
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path, true
+}
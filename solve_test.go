@@ -0,0 +1,48 @@
+package maze
+
+import "testing"
+
+func TestSolveSecrets(t *testing.T) {
+	g := parseGrid(
+		"#########",
+		"#<  $  >#",
+		"#### ####",
+		"#       #",
+		"#########",
+	)
+	start, goal := Pt(1, 1), Pt(7, 1)
+
+	if path, ok := g.Solve(start, goal); ok {
+		t.Errorf("Solve found %v through a secret passage", path)
+	}
+	if path, ok := g.SolveWith(start, goal, Moves{}); ok {
+		t.Errorf("SolveWith without secrets found %v", path)
+	}
+	path, ok := g.SolveWith(start, goal, Moves{AllowSecrets: true})
+	if !ok || len(path) != 7 {
+		t.Fatalf("SolveWith with secrets = %v, %v; want a 7-cell path", path, ok)
+	}
+	if path[3] != Pt(4, 1) {
+		t.Errorf("path %v does not pass the secret at %v", path, Pt(4, 1))
+	}
+}
+
+func TestSolve(t *testing.T) {
+	g := parseGrid(
+		"#######",
+		"#   # #",
+		"# # # #",
+		"# #   #",
+		"#######",
+	)
+	path, ok := g.Solve(Pt(1, 1), Pt(5, 1))
+	if !ok || len(path) != 9 || path[0] != Pt(1, 1) || path[8] != Pt(5, 1) {
+		t.Errorf("Solve = %v, %v; want a 9-cell path from %v to %v", path, ok, Pt(1, 1), Pt(5, 1))
+	}
+	if path, ok := g.Solve(Pt(3, 3), Pt(3, 3)); !ok || len(path) != 1 {
+		t.Errorf("Solve to itself = %v, %v; want the one cell", path, ok)
+	}
+	if _, ok := g.Solve(Pt(0, 0), Pt(5, 1)); ok {
+		t.Error("Solve from rock succeeded")
+	}
+}
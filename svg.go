@@ -0,0 +1,46 @@
+package maze
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+)
+
+// RenderSVG draws the grid as an SVG image with a square opts.Scale units
+// across per cell. Walls are drawn as lines along the runs Walls returns, so
+// the image stays sharp at any zoom. Doors, stairs, the entrance and the exit,
+// and revealed secret passages, are filled in their material colors.
+func (g *Grid) RenderSVG(w io.Writer, opts RenderOptions) error {
+	c, theme := opts.scale(SVG_CELL_SIZE), opts.theme()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d">`+"\n",
+		g.Size.X*c, g.Size.Y*c, g.Size.X*c, g.Size.Y*c)
+	fmt.Fprintf(bw, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hexColor(theme.Carved))
+
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if m := g.At(Pt(x, y)); m == Door || m == Entrance || m == Exit || m == StairsDown || m == StairsUp || m == Secret && opts.RevealSecrets {
+				fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+					x*c, y*c, c, c, hexColor(theme.material(m, opts.RevealSecrets)))
+			}
+		}
+	}
+
+	fmt.Fprintf(bw, `<path fill="none" stroke="%s" stroke-width="%d" stroke-linecap="square" d="`, hexColor(theme.Rock), max(1, c/5))
+	for _, s := range g.Walls() {
+		if s[0].Y == s[1].Y {
+			fmt.Fprintf(bw, "M%d %dH%d", s[0].X*c, s[0].Y*c, s[1].X*c)
+		} else {
+			fmt.Fprintf(bw, "M%d %dV%d", s[0].X*c, s[0].Y*c, s[1].Y*c)
+		}
+	}
+	fmt.Fprintf(bw, "\"/>\n</svg>\n")
+
+	return bw.Flush()
+}
+
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
@@ -0,0 +1,71 @@
+package maze
+
+import (
+	"bufio"
+	"io"
+)
+
+// Box-drawing wall pieces indexed by which orthogonal neighbours are walls
+// too: N=1, E=2, S=4, W=8.
+var boxWalls = []rune("■╵╶└╷│┌├╴┘─┴┐┤┬┼")
+
+// RenderText writes the grid as text, one row per line: walls as '#',
+// passable cells as spaces, doors as '+', stairs down and up as 'v' and '^',
+// and the entrance and exit as '<' and '>'. With opts.BoxDrawing the walls
+// bordering open cells are drawn with joined box-drawing lines, and solid rock
+// is left blank.
+func (g *Grid) RenderText(w io.Writer, opts RenderOptions) error {
+	bw := bufio.NewWriter(w)
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			bw.WriteRune(g.textCell(Pt(x, y), opts.BoxDrawing))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+func (g *Grid) textCell(p Point, box bool) rune {
+	switch g.At(p) {
+	case Entrance:
+		return '<'
+	case Exit:
+		return '>'
+	case Door:
+		return '+'
+	case StairsDown:
+		return 'v'
+	case StairsUp:
+		return '^'
+	}
+	if passable(g.At(p)) {
+		return ' '
+	}
+	if !box {
+		return '#'
+	}
+	if !g.visibleWall(p) {
+		return ' '
+	}
+
+	mask := 0
+	for i, d := range []direction{Dir.Up, Dir.Right, Dir.Down, Dir.Left} {
+		n := p.AddDir(d)
+		if n.In(g.Bounds()) && !passable(g.At(n)) && g.visibleWall(n) {
+			mask |= 1 << i
+		}
+	}
+	return boxWalls[mask]
+}
+
+// visibleWall reports whether the wall at p touches a passable cell, counting
+// diagonal neighbours.
+func (g *Grid) visibleWall(p Point) bool {
+	for _, d := range append(append([]direction{}, Dirs...), Diagonals...) {
+		n := p.AddDir(d)
+		if passable(g.At(n)) {
+			return true
+		}
+	}
+	return false
+}
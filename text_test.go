@@ -0,0 +1,40 @@
+package maze
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderText(t *testing.T) {
+	rows := []string{
+		"#######",
+		"#< + >#",
+		"### ###",
+		"#######",
+	}
+	g := parseGrid(rows...)
+
+	var b strings.Builder
+	if err := g.RenderText(&b, RenderOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), strings.Join(rows, "\n")+"\n"; got != want {
+		t.Errorf("RenderText() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderTextBoxDrawing(t *testing.T) {
+	g := parseGrid(
+		"#####",
+		"#<  #",
+		"#####",
+	)
+
+	var b strings.Builder
+	if err := g.RenderText(&b, RenderOptions{BoxDrawing: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "┌───┐\n│<  │\n└───┘\n"; got != want {
+		t.Errorf("RenderText() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+package maze
+
+import (
+	"fmt"
+	"image/color"
+	"image/color/palette"
+	"sort"
+)
+
+// Theme holds the colors the renderers draw with.
+type Theme struct {
+	Rock, Carved, Door, Entrance, Exit, StairsDown, StairsUp color.Color
+	// Secret passages are drawn in Rock unless RenderOptions.RevealSecrets
+	// is set.
+	Secret color.Color
+	// Connector and Path mark the open connectors and the path drawn by
+	// RenderAnnotated.
+	Connector, Path color.Color
+	// Fog covers the cells RenderVisible can't see.
+	Fog color.Color
+	// Explored marks the cells AnimateSolve's search has reached.
+	Explored color.Color
+	// Region colors the cells of region r in RenderRegions. Rock is always
+	// drawn in Rock.
+	Region func(r Region) color.Color
+}
+
+// DefaultTheme returns the theme renderers draw with unless told otherwise:
+// black rock, white passages and regions in Plan9 colors.
+func DefaultTheme() Theme {
+	return Theme{
+		Rock:       color.Black,
+		Carved:     color.White,
+		Door:       color.RGBA{150, 90, 30, 255},
+		Entrance:   color.RGBA{0, 200, 0, 255},
+		Exit:       color.RGBA{200, 0, 0, 255},
+		StairsDown: color.RGBA{0, 0, 200, 255},
+		StairsUp:   color.RGBA{0, 150, 200, 255},
+		Secret:     palette.Plan9[150],
+		Connector:  palette.Plan9[200],
+		Path:       palette.Plan9[210],
+		Fog:        color.Gray{0x40},
+		Explored:   color.RGBA{150, 190, 255, 255},
+		Region: func(r Region) color.Color {
+			return palette.Plan9[r%256]
+		},
+	}
+}
+
+// presets are the named looks Preset knows: generation settings applied over
+// DefaultConfig and colors applied over DefaultTheme.
+var presets = map[string]struct {
+	configure func(c *Config)
+	colors    func(t *Theme)
+}{
+	// Rooms of every size joined by fairly straight halls with a few loops,
+	// in worn stone.
+	"dungeon": {
+		func(c *Config) {
+			c.WindingPercent, c.ExtraConnectors = 40, 5
+		},
+		func(t *Theme) {
+			t.Rock, t.Carved = color.RGBA{45, 40, 35, 255}, color.RGBA{190, 180, 160, 255}
+			t.Door = color.RGBA{120, 70, 30, 255}
+		},
+	},
+	// Many small chambers in winding, heavily looped tunnels without
+	// pillars, in earth tones.
+	"cave": {
+		func(c *Config) {
+			c.Rooms.Min, c.Rooms.Max, c.Rooms.Roominess = Pt(3, 3), Pt(9, 9), 0.5
+			c.Rooms.Tries = 3 * ROOM_TRIES
+			c.ExtraConnectors, c.RemovePillars = 20, true
+		},
+		func(t *Theme) {
+			t.Rock, t.Carved = color.RGBA{60, 40, 25, 255}, color.RGBA{160, 120, 80, 255}
+			t.Door = color.RGBA{100, 70, 40, 255}
+		},
+	},
+	// Large halls and long straight corridors with hardly a loop, in blue
+	// and white.
+	"ice": {
+		func(c *Config) {
+			c.Rooms.Min, c.Rooms.Max, c.Rooms.Roominess = Pt(7, 7), Pt(19, 19), 2
+			c.WindingPercent, c.ExtraConnectors = 10, 1
+		},
+		func(t *Theme) {
+			t.Rock, t.Carved = color.RGBA{30, 60, 110, 255}, color.RGBA{225, 240, 255, 255}
+			t.Door = color.RGBA{120, 180, 230, 255}
+		},
+	},
+}
+
+// Preset returns the config, for a maze of the given size, and the theme of
+// the named preset look: "dungeon", "cave" or "ice". Settings may be changed
+// on the config afterwards, as for DefaultConfig.
+func Preset(name string, size Point) (Config, Theme, error) {
+	p, ok := presets[name]
+	if !ok {
+		return Config{}, Theme{}, fmt.Errorf("maze: unknown preset %q", name)
+	}
+	cfg, theme := DefaultConfig(size), DefaultTheme()
+	p.configure(&cfg)
+	p.colors(&theme)
+	return cfg, theme, nil
+}
+
+// PresetNames returns the names Preset knows, in order.
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// material returns the color of a cell of material m. Secret passages are
+// disguised as rock unless reveal is set.
+func (t Theme) material(m Material, reveal bool) color.Color {
+	switch m {
+	case Secret:
+		if reveal {
+			return t.Secret
+		}
+	case Carved:
+		return t.Carved
+	case Door:
+		return t.Door
+	case Entrance:
+		return t.Entrance
+	case Exit:
+		return t.Exit
+	case StairsDown:
+		return t.StairsDown
+	case StairsUp:
+		return t.StairsUp
+	}
+	return t.Rock
+}
+
+// palette returns the colors of paletted images: the theme's own colors and
+// black and white for rulers, followed by as many Plan9
+// colors as still fit for the region colors to be matched against.
+func (t Theme) palette() color.Palette {
+	colors := []color.Color{t.Rock, t.Carved, t.Door, t.Entrance, t.Exit, t.StairsDown, t.StairsUp,
+		t.Secret, t.Connector, t.Path, t.Fog, t.Explored, color.Black, color.White}
+	p := make(color.Palette, 0, 256)
+	for _, c := range append(colors, palette.Plan9...) {
+		if len(p) == cap(p) {
+			break
+		}
+		if q := p.Convert(c); len(p) == 0 || !sameColor(q, c) {
+			p = append(p, c)
+		}
+	}
+	return p
+}
+
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
@@ -0,0 +1,135 @@
+package maze
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func renderPNG(t *testing.T, g *Grid, opts RenderOptions) image.Image {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := g.RenderMaterials(&buf, opts); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestThemeRockColor(t *testing.T) {
+	g, _, _ := mustGenerate(t, testConfig(Pt(31, 21), 7))
+	red := color.RGBA{255, 0, 0, 255}
+	theme := DefaultTheme()
+	theme.Rock = red
+
+	const scale = 2
+	plain := renderPNG(t, g, RenderOptions{Scale: scale})
+	themed := renderPNG(t, g, RenderOptions{Scale: scale, Theme: &theme})
+
+	for y := 0; y < g.Size.Y*scale; y++ {
+		for x := 0; x < g.Size.X*scale; x++ {
+			rock := g.At(Pt(x/scale, y/scale)) == Rock
+			got := themed.At(x, y)
+			switch {
+			case rock && !sameColor(got, red):
+				t.Fatalf("rock pixel (%d, %d) is %v, want %v", x, y, got, red)
+			case !rock && !sameColor(got, plain.At(x, y)):
+				t.Fatalf("pixel (%d, %d) changed from %v to %v", x, y, plain.At(x, y), got)
+			}
+		}
+	}
+}
+
+func TestRevealSecrets(t *testing.T) {
+	g := parseGrid(
+		"#######",
+		"#  $  #",
+		"#######",
+	)
+	theme := DefaultTheme()
+	hidden := renderPNG(t, g, RenderOptions{})
+	shown := renderPNG(t, g, RenderOptions{RevealSecrets: true})
+
+	if got := hidden.At(3, 1); !sameColor(got, theme.Rock) {
+		t.Errorf("hidden secret drawn in %v, want rock %v", got, theme.Rock)
+	}
+	if got := shown.At(3, 1); !sameColor(got, theme.Secret) {
+		t.Errorf("revealed secret drawn in %v, want %v", got, theme.Secret)
+	}
+}
+
+func TestRoundCorners(t *testing.T) {
+	// A lone pillar in a room: all four of its corners stick out into the
+	// passage. The room's own corners are rock between rock and stay square.
+	g := parseGrid(
+		"#####",
+		"#   #",
+		"# # #",
+		"#   #",
+		"#####",
+	)
+	const scale = 8
+	theme := DefaultTheme()
+	square := renderPNG(t, g, RenderOptions{Scale: scale})
+	round := renderPNG(t, g, RenderOptions{Scale: scale, RoundCorners: true})
+
+	for _, px := range []Point{Pt(16, 16), Pt(23, 16), Pt(16, 23), Pt(23, 23)} {
+		if got := square.At(px.X, px.Y); !sameColor(got, theme.Rock) {
+			t.Errorf("square pillar corner %v is %v, want %v", px, got, theme.Rock)
+		}
+		if got := round.At(px.X, px.Y); !sameColor(got, theme.Carved) {
+			t.Errorf("round pillar corner %v is %v, want %v", px, got, theme.Carved)
+		}
+	}
+	if got := round.At(20, 20); !sameColor(got, theme.Rock) {
+		t.Errorf("round pillar center is %v, want %v", got, theme.Rock)
+	}
+	if got := round.At(7, 7); !sameColor(got, theme.Rock) {
+		t.Errorf("wall corner between walls is %v, want %v", got, theme.Rock)
+	}
+	if got := renderPNG(t, g, RenderOptions{Scale: 2, RoundCorners: true}).At(4, 4); !sameColor(got, theme.Rock) {
+		t.Errorf("pillar corner at scale 2 is %v, want %v", got, theme.Rock)
+	}
+}
+
+func TestPreset(t *testing.T) {
+	if names := PresetNames(); len(names) != 3 || names[0] != "cave" {
+		t.Errorf("PresetNames() = %q", names)
+	}
+	for _, name := range PresetNames() {
+		cfg, theme, err := Preset(name, Pt(41, 31))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+		if sameColor(theme.Rock, DefaultTheme().Rock) || sameColor(theme.Carved, DefaultTheme().Carved) {
+			t.Errorf("%s: theme keeps the default rock and floor colors", name)
+		}
+
+		cfg.Seed = 9
+		g, _, _ := mustGenerate(t, cfg)
+		img := renderPNG(t, g, RenderOptions{Theme: &theme})
+		for y := 0; y < g.Size.Y; y++ {
+			for x := 0; x < g.Size.X; x++ {
+				want := theme.material(g.At(Pt(x, y)), false)
+				if got := img.At(x, y); !sameColor(got, want) {
+					t.Fatalf("%s: cell (%d, %d) drawn %v, want %v", name, x, y, got, want)
+				}
+			}
+		}
+	}
+
+	if cfg, _, _ := Preset("ice", Pt(41, 31)); cfg.WindingPercent != 10 || cfg.Rooms.Roominess != 2 {
+		t.Errorf("ice preset has winding %d and roominess %g", cfg.WindingPercent, cfg.Rooms.Roominess)
+	}
+	if _, _, err := Preset("lava", Pt(41, 31)); err == nil {
+		t.Error("Preset of an unknown name succeeded")
+	}
+}
@@ -0,0 +1,120 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math/rand"
+)
+
+// GenerateTiled builds a cfg.Size maze out of chunk×chunk pieces, each made by
+// Generate from cfg with its own seed drawn from cfg.Seed, so a piece never needs more
+// working memory than chunk×chunk cells. Neighbouring pieces share their
+// border wall, and one door is opened at random through every shared wall,
+// which keeps the whole maze connected. Pieces along the right and bottom
+// edges are clipped to fit, and a strip too narrow for a piece of its own is
+// left as rock. chunk must be odd, so that every piece's lattice lines up with
+// its neighbours', and at least 3. The pieces' own entrances and exits are
+// carved over and the whole maze gets one pair, as in Generate.
+// cfg.Rooms.Fixed is ignored, since its rooms are in whole-maze coordinates;
+// cfg.Mask, cfg.Shape and cfg.Density are consulted in whole-maze
+// coordinates. The first error from a piece, such as ctx being done, is
+// returned, as is an error if two neighbouring pieces can't be joined, which a
+// mask can cause.
+func GenerateTiled(ctx context.Context, cfg Config, chunk int) (grid *Grid, entrance, exit Point, err error) {
+	if chunk < 3 || chunk%2 == 0 {
+		return nil, entrance, exit, fmt.Errorf("maze: tile size %d is not odd and at least 3", chunk)
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	size, mask, density := cfg.Size, cfg.mask(), cfg.Density
+	grid = newGrid(size)
+	cfg.Rooms.Fixed, cfg.Shape = nil, Rectangle
+	step := chunk - 1
+
+	for y0 := 0; y0 <= size.Y-3; y0 += step {
+		for x0 := 0; x0 <= size.X-3; x0 += step {
+			at := Pt(x0, y0)
+			cfg.Size, cfg.Seed = Pt(min(chunk, size.X-x0), min(chunk, size.Y-y0)), rng.Int63()
+			if mask != nil {
+				cfg.Mask = func(p Point) bool { return mask(p.Add(at)) }
+			}
+			if density != nil {
+				cfg.Density = func(p Point) float64 { return density(p.Add(at)) }
+			}
+			piece, in, out, err := Generate(ctx, cfg, nil)
+			if err != nil {
+				return nil, entrance, exit, err
+			}
+			for _, end := range []Point{in, out} {
+				if piece.At(end) != Rock {
+					piece.SetMaterial(end, Carved)
+				}
+			}
+			grid.paste(piece, at)
+
+			if x0 > 0 {
+				if err := grid.carveDoor(rng, at, Pt(x0, y0+piece.Size.Y-1), Dir.Right); err != nil {
+					return nil, entrance, exit, err
+				}
+			}
+			if y0 > 0 {
+				if err := grid.carveDoor(rng, at, Pt(x0+piece.Size.X-1, y0), Dir.Down); err != nil {
+					return nil, entrance, exit, err
+				}
+			}
+		}
+	}
+
+	entrance, exit = placeEnds(grid, cfg.Exit)
+	return grid, entrance, exit, nil
+}
+
+// paste copies piece into g with its top-left corner at at, giving its
+// regions fresh ids. Rock never overwrites what is already there, so the
+// border a piece shares with its neighbours keeps their openings.
+func (g *Grid) paste(piece *Grid, at Point) {
+	base := g.regCount
+	g.regCount += piece.regCount
+
+	for y := 0; y < piece.Size.Y; y++ {
+		for x := 0; x < piece.Size.X; x++ {
+			p := Pt(x, y)
+			if piece.At(p) == Rock {
+				continue
+			}
+			g.SetMaterial(p.Add(at), piece.At(p))
+			g.SetRegion(p.Add(at), base+piece.RegionAt(p))
+		}
+	}
+
+	for _, r := range piece.rooms {
+		g.rooms = append(g.rooms, r.Add(at.Point))
+	}
+	g.roomTypes = append(g.roomTypes, piece.roomTypes...)
+}
+
+// carveDoor opens one wall cell on the straight wall from a to b whose two
+// sides across the wall, in direction across, are both passable. It fails if
+// there is no such cell.
+func (g *Grid) carveDoor(rng *rand.Rand, a, b Point, across direction) error {
+	wall := image.Rectangle{a.Point, b.Point}.Canon()
+	doors := make([]Point, 0)
+
+	for y := wall.Min.Y; y <= wall.Max.Y; y++ {
+		for x := wall.Min.X; x <= wall.Max.X; x++ {
+			p := Pt(x, y)
+			before, after := p.AddDir(across.Reverse()), p.AddDir(across)
+			if g.At(p) == Rock && passable(g.At(before)) && passable(g.At(after)) {
+				doors = append(doors, p)
+			}
+		}
+	}
+
+	if len(doors) == 0 {
+		return fmt.Errorf("maze: no door possible in the wall from %v to %v", a, b)
+	}
+	door := doors[rng.Intn(len(doors))]
+	g.SetMaterial(door, Door)
+	g.SetRegion(door, g.RegionAt(door.AddDir(across.Reverse())))
+	return nil
+}
@@ -0,0 +1,53 @@
+package maze
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGenerateTiled(t *testing.T) {
+	for _, c := range []struct {
+		size  Point
+		chunk int
+	}{
+		{Pt(81, 81), 21},
+		{Pt(81, 61), 3},
+		{Pt(83, 41), 21},
+		{Pt(82, 42), 21},
+		{Pt(31, 31), 41},
+	} {
+		cfg := testConfig(c.size, 14)
+		g, entrance, exit, err := GenerateTiled(context.Background(), cfg, c.chunk)
+		if err != nil {
+			t.Errorf("%v in chunks of %d: %s", c.size, c.chunk, err)
+			continue
+		}
+		if err := g.Check(); err != nil {
+			t.Errorf("%v in chunks of %d: %s", c.size, c.chunk, err)
+		}
+		if _, n := g.Components(Moves{}); n != 1 {
+			t.Errorf("%v in chunks of %d: %d components, want 1", c.size, c.chunk, n)
+		}
+		if !g.EntranceConnected(entrance, exit) {
+			t.Errorf("%v in chunks of %d: exit not reachable", c.size, c.chunk)
+		}
+
+		// Like a single-pass maze, every lattice cell inside the outer wall
+		// is reached.
+		for y := 1; y < (g.Size.Y-1)/2*2; y += 2 {
+			for x := 1; x < (g.Size.X-1)/2*2; x += 2 {
+				if g.At(Pt(x, y)) == Rock {
+					t.Fatalf("%v in chunks of %d: lattice cell %v left uncarved", c.size, c.chunk, Pt(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateTiledBadChunk(t *testing.T) {
+	for _, chunk := range []int{-1, 0, 1, 2, 20} {
+		if _, _, _, err := GenerateTiled(context.Background(), testConfig(Pt(81, 81), 1), chunk); err == nil {
+			t.Errorf("GenerateTiled with chunk %d succeeded, want an error", chunk)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package maze
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+)
+
+// RenderSpanningTree draws the breadth-first spanning tree rooted at root on
+// top of the maze, one block of opts.Scale pixels square per cell. Every tree
+// edge joins the centers of a cell and its parent and is colored by the
+// child's depth, so consecutive levels get clearly different hues.
+func (g *Grid) RenderSpanningTree(w io.Writer, root Point, opts RenderOptions) error {
+	cellSize, theme := opts.scale(1), opts.theme()
+	img := image.NewPaletted(image.Rect(0, 0, g.Size.X*cellSize, g.Size.Y*cellSize), theme.palette())
+	for y := 0; y < g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			cell := image.Rect(x*cellSize, y*cellSize, (x+1)*cellSize, (y+1)*cellSize)
+			draw.Draw(img, cell, image.NewUniform(theme.material(g.At(Pt(x, y)), opts.RevealSecrets)), image.Point{}, draw.Src)
+		}
+	}
+
+	dist := g.DistanceFrom(root, Moves{})
+	thick := max(1, cellSize/4)
+	center := func(p Point) image.Point {
+		return image.Pt(p.X*cellSize+cellSize/2, p.Y*cellSize+cellSize/2)
+	}
+
+	for p, d := range FlowField(g, root, Moves{}) {
+		a, b := center(p), center(p.AddDir(d))
+		edge := image.Rectangle{a, b}.Canon()
+		edge.Min = edge.Min.Sub(image.Pt(thick/2, thick/2))
+		edge.Max = edge.Max.Add(image.Pt(thick-thick/2, thick-thick/2))
+		draw.Draw(img, edge, image.NewUniform(depthColor(dist[p])), image.Point{}, draw.Src)
+	}
+
+	return png.Encode(w, img)
+}
+
+// depthColor steps around the hue circle by the golden angle per level.
+func depthColor(depth int) color.Color {
+	h := math.Mod(float64(depth)*137.5, 360) / 60
+	x := 1 - math.Abs(math.Mod(h, 2)-1)
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g = 1, x
+	case 1:
+		r, g = x, 1
+	case 2:
+		g, b = 1, x
+	case 3:
+		g, b = x, 1
+	case 4:
+		r, b = x, 1
+	default:
+		r, b = 1, x
+	}
+	return color.RGBA{uint8(r * 255), uint8(g * 255), uint8(b * 255), 255}
+}
@@ -0,0 +1,113 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math/rand"
+)
+
+// carveUnicursal carves a single corridor without branches through every
+// cell of the odd lattice that fits in whole 2×2 blocks of lattice cells. A
+// random spanning tree is grown over the blocks; every block starts as a ring
+// of its four cells and each tree edge splices two neighbouring rings into
+// one, so the tree's outline is one loop through every cell. The loop is then
+// cut open beside the top-left cell of the first block. With a mask, only
+// blocks wholly inside it, and reachable from the first such block through
+// splices inside it, are used.
+func carveUnicursal(ctx context.Context, grid *Grid, rng *rand.Rand, onStep func(*Grid)) error {
+	blocks := Pt((grid.Size.X-1)/4, (grid.Size.Y-1)/4)
+	if blocks.X < 1 || blocks.Y < 1 {
+		return fmt.Errorf("maze: unicursal maze needs at least 5x5 cells, got %dx%d", grid.Size.X, grid.Size.Y)
+	}
+
+	tree := newGrid(Pt(2*blocks.X+1, 2*blocks.Y+1))
+	tree.mask = func(t Point) bool {
+		// A node of the tree stands for a block's 3×3 cells, an edge for the
+		// three cells splicing two blocks together.
+		span := func(v int) (int, int) {
+			if v%2 == 1 {
+				return 2*v - 1, 2*v + 2
+			}
+			return 2 * v, 2*v + 1
+		}
+		x0, x1 := span(t.X)
+		y0, y1 := span(t.Y)
+		return allowedRect(image.Rect(x0, y0, x1, y1), grid.allowed)
+	}
+	root, ok := Point{}, false
+	for j := 0; j < blocks.Y && !ok; j++ {
+		for i := 0; i < blocks.X && !ok; i++ {
+			root, ok = Pt(2*i+1, 2*j+1), tree.allowed(Pt(2*i+1, 2*j+1))
+		}
+	}
+	if !ok {
+		return fmt.Errorf("maze: no 3x3 block of cells inside the mask for a unicursal maze")
+	}
+	if err := grow(ctx, tree, root, tree.NewRegion(), Config{Algorithm: GrowingTree, WindingPercent: 100}, rng, nil); err != nil {
+		return err
+	}
+
+	links := make(map[Point][]Point)
+	link := func(a, b Point) {
+		links[a] = append(links[a], b)
+		links[b] = append(links[b], a)
+	}
+	for j := 0; j < blocks.Y; j++ {
+		for i := 0; i < blocks.X; i++ {
+			node := Pt(2*i+1, 2*j+1)
+			if tree.At(node) != Carved {
+				continue
+			}
+			tl, tr, bl, br := Pt(2*i, 2*j), Pt(2*i+1, 2*j), Pt(2*i, 2*j+1), Pt(2*i+1, 2*j+1)
+			if tree.At(node.AddDir(Dir.Up)) != Carved {
+				link(tl, tr)
+			}
+			if tree.At(node.AddDir(Dir.Left)) != Carved {
+				link(tl, bl)
+			}
+			if tree.At(node.AddDir(Dir.Right)) == Carved {
+				link(tr, tr.AddDir(Dir.Right))
+				link(br, br.AddDir(Dir.Right))
+			} else {
+				link(tr, br)
+			}
+			if tree.At(node.AddDir(Dir.Down)) == Carved {
+				link(bl, bl.AddDir(Dir.Down))
+				link(br, br.AddDir(Dir.Down))
+			} else {
+				link(bl, br)
+			}
+		}
+	}
+
+	cell := func(p Point) Point {
+		return Pt(2*p.X+1, 2*p.Y+1)
+	}
+	region := grid.NewRegion()
+	carve := func(p Point) {
+		grid.SetMaterial(p, Carved)
+		grid.SetRegion(p, region)
+	}
+
+	start := Pt(root.X-1, root.Y-1)
+	prev, cur := start, links[start][0]
+	carve(cell(start))
+	for cur != start {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		carve(Pt(prev.X+cur.X+1, prev.Y+cur.Y+1)) // between cell(prev) and cell(cur)
+		carve(cell(cur))
+		if onStep != nil {
+			onStep(grid)
+		}
+
+		next := links[cur][0]
+		if next == prev {
+			next = links[cur][1]
+		}
+		prev, cur = cur, next
+	}
+	return nil
+}
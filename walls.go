@@ -0,0 +1,39 @@
+package maze
+
+// Walls returns the boundaries between passable cells and walls, including
+// the grid border, as segments between grid corners: corner (x, y) is the top
+// left of cell (x, y). Collinear edges are joined into runs, horizontal runs
+// first, each set in row-major order. The segments trace closed loops around
+// every passable area.
+func (g *Grid) Walls() [][2]Point {
+	wall := func(p, q Point) bool {
+		return passable(g.At(p)) != passable(g.At(q))
+	}
+
+	segs := make([][2]Point, 0)
+	for y := 0; y <= g.Size.Y; y++ {
+		for x := 0; x < g.Size.X; x++ {
+			if !wall(Pt(x, y-1), Pt(x, y)) {
+				continue
+			}
+			start := x
+			for x+1 < g.Size.X && wall(Pt(x+1, y-1), Pt(x+1, y)) {
+				x++
+			}
+			segs = append(segs, [2]Point{Pt(start, y), Pt(x+1, y)})
+		}
+	}
+	for x := 0; x <= g.Size.X; x++ {
+		for y := 0; y < g.Size.Y; y++ {
+			if !wall(Pt(x-1, y), Pt(x, y)) {
+				continue
+			}
+			start := y
+			for y+1 < g.Size.Y && wall(Pt(x-1, y+1), Pt(x, y+1)) {
+				y++
+			}
+			segs = append(segs, [2]Point{Pt(x, start), Pt(x, y+1)})
+		}
+	}
+	return segs
+}
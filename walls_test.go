@@ -0,0 +1,49 @@
+package maze
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalls(t *testing.T) {
+	g := parseGrid(
+		"####",
+		"#  #",
+		"# ##",
+		"####",
+	)
+	want := [][2]Point{
+		{Pt(1, 1), Pt(3, 1)},
+		{Pt(2, 2), Pt(3, 2)},
+		{Pt(1, 3), Pt(2, 3)},
+		{Pt(1, 1), Pt(1, 3)},
+		{Pt(2, 2), Pt(2, 3)},
+		{Pt(3, 1), Pt(3, 2)},
+	}
+	if got := g.Walls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Walls() = %v, want %v", got, want)
+	}
+}
+
+func TestWallsClosed(t *testing.T) {
+	g, _, _ := mustGenerate(t, testConfig(Pt(41, 31), 11))
+
+	ends := make(map[Point]int)
+	horizontal := make(map[Point]bool)
+	for _, s := range g.Walls() {
+		for _, p := range s {
+			ends[p]++
+		}
+		if s[0].Y == s[1].Y {
+			if horizontal[s[0]] {
+				t.Errorf("horizontal runs meet at %v unmerged", s[0])
+			}
+			horizontal[s[1]] = true
+		}
+	}
+	for p, n := range ends {
+		if n%2 != 0 {
+			t.Errorf("corner %v ends %d segments; loops are not closed", p, n)
+		}
+	}
+}